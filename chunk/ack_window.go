@@ -0,0 +1,95 @@
+package chunk
+
+import (
+	"io"
+	"sync"
+)
+
+// AckWindow keeps track of the number of bytes received from a peer, and
+// determines when those bytes must be acknowledged according to the window
+// size that the peer has asked for (see sections 5.4.3 and 5.4.4 of the RTMP
+// specification).
+//
+// Sequence numbers are the total number of bytes received thus far, and wrap
+// around once they exceed the range of a 32-bit unsigned integer, as is
+// required by the specification.
+type AckWindow struct {
+	// mu guards all fields below.
+	mu sync.Mutex
+	// size is the window size, in bytes, set by the peer. A size of zero
+	// means that no acknowledgements are to be sent.
+	size uint32
+	// received is the total number of bytes received.
+	received uint32
+	// acked is the value of received when the last acknowledgement was
+	// due.
+	acked uint32
+}
+
+// SetSize sets the number of bytes that may be received before an
+// acknowledgement is due.
+func (a *AckWindow) SetSize(size uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.size = size
+}
+
+// Size returns the number of bytes that may be received before an
+// acknowledgement is due.
+func (a *AckWindow) Size() uint32 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.size
+}
+
+// Received returns the total number of bytes received, modulo 2^32.
+func (a *AckWindow) Received() uint32 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.received
+}
+
+// Add records that `n` more bytes have been received.
+func (a *AckWindow) Add(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.received += uint32(n)
+}
+
+// Due determines whether the number of bytes received since the last
+// acknowledgement has reached the window size. If it has, the sequence number
+// to acknowledge is returned along with a value of true, and the window starts
+// over. Otherwise, false is returned.
+func (a *AckWindow) Due() (uint32, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.size == 0 || a.received-a.acked < a.size {
+		return 0, false
+	}
+
+	a.acked = a.received
+
+	return a.received, true
+}
+
+// countingReader is an io.Reader that records the number of bytes read through
+// it in an AckWindow.
+type countingReader struct {
+	// src is the io.Reader being counted.
+	src io.Reader
+	// window is the AckWindow that bytes read are added to.
+	window *AckWindow
+}
+
+// Read implements io.Reader.Read.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.src.Read(p)
+	c.window.Add(n)
+
+	return n, err
+}
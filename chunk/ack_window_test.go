@@ -0,0 +1,51 @@
+package chunk_test
+
+import (
+	"testing"
+
+	"github.com/WatchBeam/rtmp/chunk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAckWindowIsNeverDueWithoutASize(t *testing.T) {
+	w := new(chunk.AckWindow)
+	w.Add(1 << 20)
+
+	_, due := w.Due()
+
+	assert.False(t, due)
+	assert.Equal(t, uint32(1<<20), w.Received())
+}
+
+func TestAckWindowIsDueAfterSizeBytes(t *testing.T) {
+	w := new(chunk.AckWindow)
+	w.SetSize(10)
+
+	w.Add(9)
+	_, due := w.Due()
+	assert.False(t, due)
+
+	w.Add(2)
+	seq, due := w.Due()
+	assert.True(t, due)
+	assert.Equal(t, uint32(11), seq)
+
+	w.Add(9)
+	_, due = w.Due()
+	assert.False(t, due)
+}
+
+func TestAckWindowSequenceNumbersWrapAround(t *testing.T) {
+	w := new(chunk.AckWindow)
+	w.SetSize(0x100)
+
+	w.Add(0xffffff00)
+	seq, due := w.Due()
+	assert.True(t, due)
+	assert.Equal(t, uint32(0xffffff00), seq)
+
+	w.Add(0x110)
+	seq, due = w.Due()
+	assert.True(t, due)
+	assert.Equal(t, uint32(0x10), seq)
+}
@@ -0,0 +1,84 @@
+package chunk
+
+import "sync"
+
+// backlog holds the chunks read for a chunk stream that its consumer has yet to
+// take, and delivers them in order from its own goroutine (see deliver). Chunks
+// are pushed without ever blocking, so that a consumer which stops reading its
+// chunk stream does not keep chunks from being read for the others.
+type backlog struct {
+	// mu guards chunks and finished
+	mu sync.Mutex
+	// chunks are the chunks which have yet to be delivered.
+	chunks []*Chunk
+	// finished is true once no more chunks will be pushed.
+	finished bool
+
+	// ready is signaled when chunks are pushed, or the backlog finishes.
+	ready chan struct{}
+}
+
+// newBacklog returns a new, empty *backlog.
+func newBacklog() *backlog {
+	return &backlog{ready: make(chan struct{}, 1)}
+}
+
+// push adds the given chunk to the end of the backlog.
+func (b *backlog) push(c *Chunk) {
+	b.mu.Lock()
+	b.chunks = append(b.chunks, c)
+	b.mu.Unlock()
+
+	b.signal()
+}
+
+// finish causes deliver to return once every chunk pushed has been delivered.
+func (b *backlog) finish() {
+	b.mu.Lock()
+	b.finished = true
+	b.mu.Unlock()
+
+	b.signal()
+}
+
+// signal wakes deliver up, if it is not awake already.
+func (b *backlog) signal() {
+	select {
+	case b.ready <- struct{}{}:
+	default:
+	}
+}
+
+// deliver sends the chunks pushed down the given channel, in order, until the
+// backlog has been finished and emptied, or the stop channel is closed.
+//
+// deliver runs within its own goroutine.
+func (b *backlog) deliver(to chan<- *Chunk, stop <-chan struct{}) {
+	for {
+		b.mu.Lock()
+		chunks, finished := b.chunks, b.finished
+		b.chunks = nil
+		b.mu.Unlock()
+
+		if len(chunks) == 0 {
+			if finished {
+				return
+			}
+
+			select {
+			case <-b.ready:
+				continue
+			case <-stop:
+				return
+			}
+		}
+
+		for _, c := range chunks {
+			select {
+			case to <- c:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
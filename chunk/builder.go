@@ -21,12 +21,14 @@ type Builder struct {
 	// you.
 	Header *Header
 
-	// pmu guards Builder.Payloads.
+	// pmu guards payload and n
 	pmu sync.Mutex
-	// Payloads holds a slice of slices, each sub-slice being one part of
-	// the payload received at a time. These slices are appended together to
-	// form a complete chunk.
-	Payloads [][]byte
+	// payload is the buffer that the message's payload is read into. It
+	// holds as many bytes as the Length of the Header, and is taken from
+	// a pool the first time that it is needed.
+	payload *[]byte
+	// n is the number of bytes of the payload received thus far.
+	n int
 
 	// lmu guards left
 	lmu sync.Mutex
@@ -46,51 +48,103 @@ func NewBuilder(h *Header) *Builder {
 }
 
 // Build builds a returns a Chunk formed by using the given header for the
-// chunk's header, and the concatenation of all of the received payloads as the
-// chunk body.
+// chunk's header, and the payload received thus far as the chunk body. The
+// payload is handed over to the Chunk without being copied, and may be handed
+// back to the pool that it was taken from with Chunk.Release. Build must only
+// be called once.
 func (b *Builder) Build() *Chunk {
 	b.pmu.Lock()
 	defer b.pmu.Unlock()
 
-	var payload []byte
-	for _, partial := range b.Payloads {
-		payload = append(payload, partial...)
+	c := New(b.Header, nil)
+	if b.payload != nil {
+		c.Data = (*b.payload)[:b.n]
+		c.buf = b.payload
+
+		b.payload = nil
 	}
 
-	return New(b.Header, payload)
+	return c
+}
+
+// Discard hands the payload received thus far back to the pool that it was
+// taken from, such as when the message is aborted. The Builder must not be
+// used afterwards.
+func (b *Builder) Discard() {
+	b.pmu.Lock()
+	defer b.pmu.Unlock()
+
+	if b.payload != nil {
+		putBuffer(b.payload)
+		b.payload = nil
+	}
 }
 
-// Read reads the given number of bytes from the specified io.Reader, and
-// appends them as a slice. It returns the number of bytes read, and any error
-// encountered (if applicable).
+// Read reads the given number of bytes from the specified io.Reader directly
+// into the payload. It returns the number of bytes read, and any error
+// encountered (if applicable). Bytes of failed reads are not counted towards
+// the payload, and if reading `n` bytes would exceed the Length of the
+// message, ErrTooManyBytes is returned without reading anything.
 func (b *Builder) Read(r io.Reader, n int) (int, error) {
-	buf := make([]byte, n)
-	n, err := io.ReadFull(r, buf)
+	b.pmu.Lock()
+	defer b.pmu.Unlock()
+
+	buf, err := b.next(n)
+	if err != nil {
+		return 0, err
+	}
+
+	read, err := io.ReadFull(r, buf)
 	if err != nil {
-		return n, err
+		return read, err
 	}
 
-	return b.Append(buf)
+	b.received(n)
+
+	return n, nil
 }
 
-// Append appends the given slice to the payloads received thus far, subtracting
-// the number of bytes read from the number of bytes left to be read. If too may
-// bytes were appended (i.e., left < 0), then ErrTooManyBytes will be returned
-// instead.
+// Append copies the given slice into the payload after the bytes received thus
+// far, subtracting the number of bytes appended from the number of bytes left
+// to be read. If too many bytes would be appended (i.e., left < 0), then
+// ErrTooManyBytes will be returned instead, and nothing is appended.
 func (b *Builder) Append(slice []byte) (int, error) {
 	b.pmu.Lock()
 	defer b.pmu.Unlock()
 
-	b.Payloads = append(b.Payloads, slice)
-	b.left -= len(slice)
-
-	if b.left < 0 {
-		return len(slice), ErrTooManyBytes
+	buf, err := b.next(len(slice))
+	if err != nil {
+		return 0, err
 	}
 
+	copy(buf, slice)
+	b.received(len(slice))
+
 	return len(slice), nil
 }
 
+// next returns the part of the payload that the next `n` bytes received should
+// be written into, taking the payload from a pool if this is the first time
+// that it is needed. It must be called with pmu held.
+func (b *Builder) next(n int) ([]byte, error) {
+	if n > b.BytesLeft() {
+		return nil, ErrTooManyBytes
+	}
+
+	if b.payload == nil {
+		b.payload = getBuffer(b.n + b.BytesLeft())
+	}
+
+	return (*b.payload)[b.n : b.n+n], nil
+}
+
+// received records that `n` more bytes of the payload have been received. It
+// must be called with pmu held.
+func (b *Builder) received(n int) {
+	b.n += n
+	b.AddLeft(-n)
+}
+
 // AddLeft adds the delta parameter to the amount of bytes left by using the
 // guarding mutex.
 func (b *Builder) AddLeft(delta int) {
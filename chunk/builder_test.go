@@ -27,7 +27,7 @@ func TestReadAppendsBytes(t *testing.T) {
 
 	assert.Nil(t, err)
 	assert.Equal(t, 8, n)
-	assert.Equal(t, slice, builder.Payloads[0])
+	assert.Equal(t, slice, builder.Build().Data)
 }
 
 func TestReadDoesNotAppendFailedReads(t *testing.T) {
@@ -40,7 +40,21 @@ func TestReadDoesNotAppendFailedReads(t *testing.T) {
 
 	assert.Equal(t, io.EOF, err)
 	assert.Equal(t, 0, n)
-	assert.Empty(t, builder.Payloads)
+	assert.Equal(t, 8, builder.BytesLeft())
+	assert.Empty(t, builder.Build().Data)
+}
+
+func TestReadDoesNotReadPastTheEndOfTheMessage(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{0x00, 0x01, 0x02, 0x03})
+	builder := chunk.NewBuilder(&chunk.Header{
+		MessageHeader: chunk.MessageHeader{Length: 2},
+	})
+
+	n, err := builder.Read(buf, 4)
+
+	assert.Equal(t, chunk.ErrTooManyBytes, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 4, buf.Len())
 }
 
 func TestAppendAddsSingleSliceWithinBounds(t *testing.T) {
@@ -53,7 +67,7 @@ func TestAppendAddsSingleSliceWithinBounds(t *testing.T) {
 
 	assert.Nil(t, err)
 	assert.Equal(t, 8, n)
-	assert.Equal(t, slice, b.Payloads[0])
+	assert.Equal(t, slice, b.Build().Data)
 }
 
 func TestAppendAddsNSliceWithinBounds(t *testing.T) {
@@ -61,7 +75,7 @@ func TestAppendAddsNSliceWithinBounds(t *testing.T) {
 		MessageHeader: chunk.MessageHeader{Length: 8},
 	})
 
-	for i, slice := range [][]byte{
+	for _, slice := range [][]byte{
 		[]byte{0x00, 0x01, 0x02, 0x03},
 		[]byte{0x04, 0x05, 0x06, 0x07},
 	} {
@@ -69,20 +83,33 @@ func TestAppendAddsNSliceWithinBounds(t *testing.T) {
 
 		assert.Nil(t, err)
 		assert.Equal(t, len(slice), n)
-		assert.Equal(t, slice, b.Payloads[i])
 	}
 
-	assert.Equal(t, 2, len(b.Payloads))
+	assert.Equal(t, 0, b.BytesLeft())
+	assert.Equal(t, []byte{
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
+	}, b.Build().Data)
+}
+
+func TestAppendRejectsSlicesOutOfBounds(t *testing.T) {
+	b := chunk.NewBuilder(&chunk.Header{
+		MessageHeader: chunk.MessageHeader{Length: 2},
+	})
+
+	n, err := b.Append([]byte{0x00, 0x01, 0x02})
+
+	assert.Equal(t, chunk.ErrTooManyBytes, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 2, b.BytesLeft())
 }
 
 func TestChunkBuildersBuildValidChunks(t *testing.T) {
-	header := new(chunk.Header)
-	builder := &chunk.Builder{
-		Header: header,
-		Payloads: [][]byte{
-			[]byte{0x01}, []byte{0x02},
-		},
+	header := &chunk.Header{
+		MessageHeader: chunk.MessageHeader{Length: 2},
 	}
+	builder := chunk.NewBuilder(header)
+	builder.Append([]byte{0x01})
+	builder.Append([]byte{0x02})
 
 	c := builder.Build()
 
@@ -90,3 +117,23 @@ func TestChunkBuildersBuildValidChunks(t *testing.T) {
 	assert.Equal(t, header, c.Header)
 	assert.Equal(t, []byte{0x01, 0x02}, c.Data)
 }
+
+func TestReleaseHandsBackTheBuiltPayload(t *testing.T) {
+	builder := chunk.NewBuilder(&chunk.Header{
+		MessageHeader: chunk.MessageHeader{Length: 2},
+	})
+	builder.Append([]byte{0x01, 0x02})
+
+	c := builder.Build()
+	c.Release()
+	c.Release()
+
+	assert.Nil(t, c.Data)
+}
+
+func TestReleaseLeavesUnpooledPayloadsAlone(t *testing.T) {
+	c := chunk.New(new(chunk.Header), []byte{0x01, 0x02})
+	c.Release()
+
+	assert.Equal(t, []byte{0x01, 0x02}, c.Data)
+}
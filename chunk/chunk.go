@@ -8,6 +8,14 @@ type Chunk struct {
 	// Data is the chunk's payload, and has a length equal to `Length` field
 	// given in the Header.MessageHeader.
 	Data []byte
+
+	// Seq is the order in which the chunk was read by a Parser, across all
+	// chunk streams. It starts at zero, and is left alone by everything
+	// else.
+	Seq uint64
+
+	// buf is the pooled buffer backing Data, if any. See Release.
+	buf *[]byte
 }
 
 // New returns a new Chunk initialized with the given Header and Data fields.
@@ -18,6 +26,22 @@ func New(header *Header, data []byte) *Chunk {
 	}
 }
 
+// Release hands the buffer backing the chunk's Data back to the pool that it was
+// taken from by the Builder, if any, so that it may be re-used for another
+// message. Neither the Data, nor any slice of it, may be used afterwards. It is
+// safe to call Release on chunks whose Data is not pooled, and more than once,
+// though not from multiple goroutines at once.
+func (c *Chunk) Release() {
+	if c.buf == nil {
+		return
+	}
+
+	putBuffer(c.buf)
+
+	c.buf = nil
+	c.Data = nil
+}
+
 // StreamId returns the ID of the RTMP chunk stream that this Chunk belongs to.
 func (c *Chunk) StreamId() uint32 { return c.Header.BasicHeader.StreamId }
 
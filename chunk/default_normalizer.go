@@ -27,15 +27,13 @@ func NewNormalizer() Normalizer {
 
 // Normalize implements the `Normalize` func from the Normalizer interface.
 func (n *DefaultNormalizer) Normalize(h *Header) *Header {
-	last := n.Last()
-	lastSameStream := n.Header(h.BasicHeader.StreamId)
-
+	last := n.Header(h.BasicHeader.StreamId)
 	if last != nil {
 		n.fillPartialHeader(last, h)
+		n.fillEmptyHeader(last, h)
 	}
-	if lastSameStream != nil {
-		n.fillEmptyHeader(lastSameStream, h)
-	}
+
+	n.fillTimestamp(last, h)
 
 	n.SetLast(h)
 	n.StoreHeader(h)
@@ -76,8 +74,8 @@ func (n *DefaultNormalizer) StoreHeader(h *Header) {
 	n.headers[streamId] = h
 }
 
-// fillPartialHeader fills in partially empty chunk message headers, according
-// to the RTMP spec.
+// fillPartialHeader fills in partially empty chunk message headers from the
+// last header received over the same chunk stream, according to the RTMP spec.
 func (n *DefaultNormalizer) fillPartialHeader(last *Header, h *Header) {
 	fmtId := h.BasicHeader.FormatId
 	if fmtId != 1 && fmtId != 2 {
@@ -88,6 +86,7 @@ func (n *DefaultNormalizer) fillPartialHeader(last *Header, h *Header) {
 
 	if fmtId == 2 {
 		h.MessageHeader.Length = last.MessageHeader.Length
+		h.MessageHeader.TypeId = last.MessageHeader.TypeId
 	}
 }
 
@@ -99,4 +98,23 @@ func (n *DefaultNormalizer) fillEmptyHeader(last *Header, h *Header) {
 	}
 
 	h.MessageHeader = last.MessageHeader
+	h.MessageHeader.FormatId = 3
+	h.MessageHeader.TimestampDelta = true
+}
+
+// fillTimestamp computes the absolute timestamp of the given header by adding
+// its timestamp delta to the absolute timestamp of the last header received
+// over the same chunk stream. Type 0 headers carry an absolute timestamp, and
+// type 3 headers re-use the timestamp field of the last header (filled in by
+// fillEmptyHeader). Extended timestamps are taken into account.
+//
+// Timestamps are 32-bit values and wrap around (roughly every 49 days)
+// according to the rules of unsigned integer overflow.
+func (n *DefaultNormalizer) fillTimestamp(last *Header, h *Header) {
+	if !h.MessageHeader.TimestampDelta || last == nil {
+		h.Timestamp = h.TimestampField()
+		return
+	}
+
+	h.Timestamp = last.Timestamp + h.TimestampField()
 }
@@ -71,3 +71,103 @@ func TestNormalizingFillsMissingHeaders(t *testing.T) {
 	assert.Equal(t, uint32(4), h.MessageHeader.Timestamp)
 	assert.Equal(t, uint32(5), h.MessageHeader.Length)
 }
+
+func TestNormalizingFillsPartialHeadersFromSameChunkStream(t *testing.T) {
+	n := NewNormalizer()
+	n.Normalize(&Header{
+		BasicHeader: BasicHeader{FormatId: 0, StreamId: 6},
+		MessageHeader: MessageHeader{
+			Length:   8,
+			TypeId:   9,
+			StreamId: 1,
+		},
+	})
+	n.Normalize(&Header{
+		BasicHeader: BasicHeader{FormatId: 0, StreamId: 7},
+		MessageHeader: MessageHeader{
+			Length:   2,
+			TypeId:   8,
+			StreamId: 2,
+		},
+	})
+
+	h := n.Normalize(&Header{
+		BasicHeader: BasicHeader{FormatId: 2, StreamId: 6},
+	})
+
+	assert.Equal(t, uint32(8), h.MessageHeader.Length)
+	assert.Equal(t, byte(9), h.MessageHeader.TypeId)
+	assert.Equal(t, uint32(1), h.MessageHeader.StreamId)
+}
+
+func TestNormalizingAccumulatesTimestamps(t *testing.T) {
+	n := NewNormalizer()
+
+	for _, tc := range []struct {
+		FormatId  byte
+		Timestamp uint32
+		Expected  uint32
+	}{
+		{0, 1000, 1000},
+		{1, 40, 1040},
+		{2, 20, 1060},
+		{3, 0, 1080},
+		{3, 0, 1100},
+		{0, 500, 500},
+	} {
+		h := &Header{
+			BasicHeader: BasicHeader{FormatId: tc.FormatId, StreamId: 6},
+			MessageHeader: MessageHeader{
+				FormatId:       tc.FormatId,
+				Timestamp:      tc.Timestamp,
+				TimestampDelta: tc.FormatId != 0,
+			},
+		}
+
+		h = n.Normalize(h)
+
+		assert.Equal(t, tc.Expected, h.Timestamp)
+	}
+}
+
+func TestNormalizingTimestampsWrapAround(t *testing.T) {
+	n := NewNormalizer()
+	n.Normalize(&Header{
+		BasicHeader:   BasicHeader{FormatId: 0, StreamId: 6},
+		MessageHeader: MessageHeader{Timestamp: 0xffffffff - 9},
+	})
+
+	h := n.Normalize(&Header{
+		BasicHeader: BasicHeader{FormatId: 2, StreamId: 6},
+		MessageHeader: MessageHeader{
+			FormatId:       2,
+			Timestamp:      20,
+			TimestampDelta: true,
+		},
+	})
+
+	assert.Equal(t, uint32(10), h.Timestamp)
+}
+
+func TestNormalizingTracksTimestampsPerChunkStream(t *testing.T) {
+	n := NewNormalizer()
+	n.Normalize(&Header{
+		BasicHeader:   BasicHeader{FormatId: 0, StreamId: 6},
+		MessageHeader: MessageHeader{Timestamp: 100},
+	})
+	n.Normalize(&Header{
+		BasicHeader:   BasicHeader{FormatId: 0, StreamId: 7},
+		MessageHeader: MessageHeader{Timestamp: 5000},
+	})
+
+	h := n.Normalize(&Header{
+		BasicHeader: BasicHeader{FormatId: 1, StreamId: 6},
+		MessageHeader: MessageHeader{
+			FormatId:       1,
+			Timestamp:      10,
+			TimestampDelta: true,
+		},
+	})
+
+	assert.Equal(t, uint32(110), h.Timestamp)
+}
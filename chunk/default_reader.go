@@ -2,6 +2,7 @@ package chunk
 
 import (
 	"encoding/binary"
+	"errors"
 	"io"
 	"sync"
 
@@ -13,11 +14,39 @@ const (
 	DefaultReadSize int = 128
 )
 
+var (
+	// ErrUnknownChunkStream is returned when the peer sends a type 1, 2 or
+	// 3 header over a chunk stream which has never carried a type 0
+	// header, leaving nothing for it to be compressed against.
+	ErrUnknownChunkStream = errors.New("rtmp: compressed header on unknown chunk stream")
+)
+
+// WindowUpdater is the window of outgoing bytes (such as a
+// *control.FlowWriter) which is updated by the Acknowledgement (type 3) and Set
+// Peer Bandwidth (type 6) messages sent by the peer.
+type WindowUpdater interface {
+	// UpdateWindow acts upon the given Acknowledgement or Set Peer
+	// Bandwidth message, returning any error which should stop reading.
+	UpdateWindow(c *Chunk) error
+}
+
 // DefaultReader provides an RTMP-compliant implementation to the Reader
 // interface.
 type DefaultReader struct {
-	// src is the io.Reader that the multiplexed chunks are read from.
+	// src is the io.Reader that the multiplexed chunks are read from. All
+	// bytes read from it are counted in the window.
 	src io.Reader
+	// window keeps track of the bytes read from src that have yet to be
+	// acknowledged.
+	window *AckWindow
+	// peerWindow is updated by the flow control messages read, if set by
+	// SetWindowUpdater.
+	peerWindow WindowUpdater
+
+	// lmu guards limits
+	lmu sync.Mutex
+	// limits bounds the resources used on behalf of the peer.
+	limits Limits
 
 	// bmu guards builders
 	bmu sync.Mutex
@@ -25,6 +54,13 @@ type DefaultReader struct {
 	// chunk has been fully read, this entry is removed.
 	builders map[uint32]*Builder
 
+	// emu guards extended
+	emu sync.Mutex
+	// extended maps the chunk stream ID to whether or not the last type 0,
+	// 1 or 2 header received over that chunk stream had an
+	// ExtendedTimestamp, in which case type 3 headers have one, too.
+	extended map[uint32]bool
+
 	// normalizer is the Normalizer used to normalize incoming headers.
 	normalizer Normalizer
 
@@ -39,8 +75,18 @@ type DefaultReader struct {
 	// errs is used to keep track of errors that occur during the decoding
 	// procesr.
 	errs chan error
+	// acks is a buffered channel holding the latest sequence number that is
+	// due to be acknowledged.
+	acks chan uint32
 	// closer is a non-buffered channel used to pass closing signal around.
 	closer chan struct{}
+	// done is closed once Recv has returned.
+	done chan struct{}
+
+	// fmu guards err
+	fmu sync.Mutex
+	// err is the fatal error which caused Recv to return, if any.
+	err error
 }
 
 var _ Reader = new(DefaultReader)
@@ -48,11 +94,34 @@ var _ Reader = new(DefaultReader)
 // Chunks implements the `Chunks` func in the Reader interface.
 func (r *DefaultReader) Chunks() <-chan *Chunk { return r.chunks }
 
-// Errs implements the `Errs` func in the Reader interface.
+// Errs implements the `Errs` func in the Reader interface. Since a
+// DefaultReader can not recover from any of the errors that it encounters,
+// nothing is ever sent over this channel; see Err instead.
 func (r *DefaultReader) Errs() <-chan error { return r.errs }
 
-// Close implements the `Close` func in the Reader interface.
-func (r *DefaultReader) Close() { r.closer <- struct{}{} }
+// Acks implements the `Acks` func in the Reader interface.
+func (r *DefaultReader) Acks() <-chan uint32 { return r.acks }
+
+// Close implements the `Close` func in the Reader interface. If Recv is blocked
+// reading from the source stream, Close blocks until that read returns, which
+// may be forced by closing the source stream.
+func (r *DefaultReader) Close() {
+	select {
+	case r.closer <- struct{}{}:
+	case <-r.done:
+	}
+}
+
+// Done implements the `Done` func in the Reader interface.
+func (r *DefaultReader) Done() <-chan struct{} { return r.done }
+
+// Err implements the `Err` func in the Reader interface.
+func (r *DefaultReader) Err() error {
+	r.fmu.Lock()
+	defer r.fmu.Unlock()
+
+	return r.err
+}
 
 // ReadSize implements the `ReadSize` func in the Reader interface.
 func (r *DefaultReader) ReadSize() int {
@@ -70,66 +139,306 @@ func (r *DefaultReader) SetReadSize(size int) {
 	r.readSize = size
 }
 
-// Recv implements the `Recv` func in the Reader interface.
+// Limits returns the Limits in effect.
+func (r *DefaultReader) Limits() Limits {
+	r.lmu.Lock()
+	defer r.lmu.Unlock()
+
+	return r.limits
+}
+
+// SetLimits changes the Limits in effect. Resources already in use are not
+// checked against the new Limits until they grow.
+func (r *DefaultReader) SetLimits(limits Limits) {
+	r.lmu.Lock()
+	defer r.lmu.Unlock()
+
+	r.limits = limits
+}
+
+// SetWindowUpdater sets the WindowUpdater that Acknowledgement and Set Peer
+// Bandwidth messages are handed to as soon as they have been read, before they
+// are pushed over the Chunks() channel (or returned by ReadMessage). They are
+// thus acted upon even while their chunk stream is not being read, such as when
+// its consumer is blocked on the very window that they update. This method is
+// _not_ safe to use between multiple goroutines, and must be called before Recv.
+func (r *DefaultReader) SetWindowUpdater(w WindowUpdater) { r.peerWindow = w }
+
+// Recv implements the `Recv` func in the Reader interface. Any error
+// encountered while reading, including the source stream reaching EOF and one
+// of the Limits being exceeded, is fatal: it is recorded as the Err, and Recv
+// returns.
 func (r *DefaultReader) Recv() {
+	defer close(r.done)
+
 	for {
 		select {
 		case <-r.closer:
 			return
 		default:
-			header := new(Header)
-			if err := header.Read(r.src); err != nil {
-				r.errs <- err
-				continue
+			chunk, err := r.readChunk()
+			if err != nil {
+				r.setErr(err)
+				return
 			}
-			header = r.normalizer.Normalize(header)
 
-			builder := r.builder(header)
-			n := spec.Min(builder.BytesLeft(), r.ReadSize())
-
-			if _, err := builder.Read(r.src, n); err != nil {
-				r.errs <- err
+			if chunk == nil {
 				continue
 			}
 
-			if builder.BytesLeft() == 0 {
-				chunk := builder.Build()
+			select {
+			case r.chunks <- chunk:
+			case <-r.closer:
+				return
+			}
+		}
+	}
+}
 
-				if !r.updateChunkSize(chunk) {
-					r.chunks <- chunk
-				}
+// ReadMessage reads chunks off of the source stream until a message has been
+// completely read, and returns it. It is a synchronous alternative to Recv,
+// acting upon protocol control messages in the same way, and pushing sequence
+// numbers over the Acks() channel without blocking. Errors are returned as
+// they are encountered, and are just as fatal as they are to Recv, though they
+// are not recorded as the Err.
+//
+// ReadMessage must not be called while Recv is running, nor from multiple
+// goroutines at once.
+func (r *DefaultReader) ReadMessage() (*Chunk, error) {
+	for {
+		chunk, err := r.readChunk()
+		if err != nil || chunk != nil {
+			return chunk, err
+		}
+	}
+}
 
-				r.removeBuilder(header.BasicHeader.StreamId)
-			}
+// setErr records the fatal error which caused Recv to return.
+func (r *DefaultReader) setErr(err error) {
+	r.fmu.Lock()
+	defer r.fmu.Unlock()
+
+	r.err = err
+}
+
+// readChunk reads a single chunk off of the source stream, and returns the
+// message that it completes, if any. Set Chunk Size messages are swallowed, and
+// flow control messages are handed to the WindowUpdater (if there is one).
+func (r *DefaultReader) readChunk() (*Chunk, error) {
+	header, err := r.readHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	builder, err := r.builder(header)
+	if err != nil {
+		return nil, err
+	}
+
+	n := spec.Min(builder.BytesLeft(), r.ReadSize())
+	if _, err := builder.Read(r.src, n); err != nil {
+		return nil, err
+	}
+
+	var chunk *Chunk
+	if builder.BytesLeft() == 0 {
+		chunk = builder.Build()
+		r.removeBuilder(header.BasicHeader.StreamId)
+
+		r.abortMessage(chunk)
+		r.updateWindowSize(chunk)
+
+		if err := r.updatePeerWindow(chunk); err != nil {
+			return nil, err
+		}
+
+		swallowed, err := r.updateChunkSize(chunk)
+		if err != nil {
+			return nil, err
+		}
+
+		if swallowed {
+			chunk.Release()
+			chunk = nil
 		}
 	}
+
+	r.acknowledge()
+
+	return chunk, nil
 }
 
-func (r *DefaultReader) updateChunkSize(c *Chunk) bool {
+// readHeader reads the next chunk header off of the source stream, including
+// the ExtendedTimestamp of type 3 headers, which is present only when the last
+// type 0, 1 or 2 header over the same chunk stream had one. Every chunk stream
+// must begin with a type 0 header, which counts its ID towards the
+// MaxChunkStreamIds.
+func (r *DefaultReader) readHeader() (*Header, error) {
+	header := new(Header)
+	if err := header.Read(r.src); err != nil {
+		return nil, err
+	}
+
+	r.emu.Lock()
+	defer r.emu.Unlock()
+
+	streamId := header.BasicHeader.StreamId
+	if _, seen := r.extended[streamId]; !seen {
+		if header.BasicHeader.FormatId != 0 {
+			return nil, ErrUnknownChunkStream
+		}
+
+		max := r.Limits().MaxChunkStreamIds
+		if max > 0 && len(r.extended) >= max {
+			return nil, ErrTooManyChunkStreams
+		}
+	}
+
+	if header.BasicHeader.FormatId != 3 {
+		r.extended[streamId] = header.MessageHeader.HasExtendedTimestamp()
+	} else if r.extended[streamId] {
+		if err := header.ReadExtendedTimestamp(r.src); err != nil {
+			return nil, err
+		}
+	}
+
+	return header, nil
+}
+
+// updateChunkSize sets the ReadSize from a Set Chunk Size message (type 1),
+// returning true if the given chunk was one, or an error if the chunk size is
+// not acceptable.
+func (r *DefaultReader) updateChunkSize(c *Chunk) (bool, error) {
 	if c.TypeId() != byte(0x01) {
-		return false
+		return false, nil
+	}
+
+	if len(c.Data) < 4 {
+		return true, ErrInvalidChunkSize
+	}
+
+	// The most significant bit is reserved, and must be ignored.
+	size := binary.BigEndian.Uint32(c.Data) & 0x7fffffff
+	if size == 0 {
+		return true, ErrInvalidChunkSize
+	}
+
+	if max := r.Limits().MaxChunkSize; max > 0 && size > max {
+		return true, ErrChunkSizeTooLarge
+	}
+
+	r.SetReadSize(int(size))
+
+	return true, nil
+}
+
+// abortMessage discards the partially read message on the chunk stream named
+// by the given Abort Message (type 2), if there is one. The Abort Message
+// itself is not swallowed, so that consumers of the control stream may observe
+// it.
+func (r *DefaultReader) abortMessage(c *Chunk) {
+	if c.TypeId() != byte(0x02) || len(c.Data) < 4 {
+		return
+	}
+
+	r.removeBuilder(binary.BigEndian.Uint32(c.Data))
+}
+
+// updateWindowSize sets the size of the acknowledgement window from a Window
+// Acknowledgement Size message (type 5).
+func (r *DefaultReader) updateWindowSize(c *Chunk) {
+	if c.TypeId() != byte(0x05) || len(c.Data) < 4 {
+		return
+	}
+
+	r.window.SetSize(binary.BigEndian.Uint32(c.Data))
+}
+
+// updatePeerWindow hands Acknowledgement (type 3) and Set Peer Bandwidth (type
+// 6) messages to the WindowUpdater, if there is one.
+func (r *DefaultReader) updatePeerWindow(c *Chunk) error {
+	if r.peerWindow == nil {
+		return nil
 	}
 
-	r.SetReadSize(int(binary.BigEndian.Uint32(c.Data)))
+	switch c.TypeId() {
+	case 0x03, 0x06:
+		return r.peerWindow.UpdateWindow(c)
+	default:
+		return nil
+	}
+}
+
+// acknowledge pushes the sequence number that is due to be acknowledged (if
+// any) over the acks channel, replacing any sequence number that has not yet
+// been taken.
+func (r *DefaultReader) acknowledge() {
+	seq, due := r.window.Due()
+	if !due {
+		return
+	}
+
+	select {
+	case <-r.acks:
+	default:
+	}
 
-	return true
+	r.acks <- seq
 }
 
-func (r *DefaultReader) builder(header *Header) *Builder {
+// builder returns the Builder for the message in progress over the chunk stream
+// that the given header belongs to. If there is no such message, the header is
+// normalized and a new Builder is started, provided that the message is no
+// longer than the MaxMessageSize, and that its payload fits within the
+// MaxBytesInFlight. Headers of continuation chunks are not normalized, since
+// they do not begin a new message.
+func (r *DefaultReader) builder(header *Header) (*Builder, error) {
 	r.bmu.Lock()
 	defer r.bmu.Unlock()
 
 	streamId := header.BasicHeader.StreamId
 	if r.builders[streamId] == nil {
+		header = r.normalizer.Normalize(header)
+
+		limits := r.Limits()
+		length := header.MessageHeader.Length
+
+		if max := limits.MaxMessageSize; max > 0 && length > max {
+			return nil, ErrMessageTooLarge
+		}
+
+		if max := limits.MaxBytesInFlight; max > 0 &&
+			r.inFlight()+int(length) > max {
+
+			return nil, ErrTooManyBytesInFlight
+		}
+
 		r.builders[streamId] = NewBuilder(header)
 	}
 
-	return r.builders[streamId]
+	return r.builders[streamId], nil
+}
+
+// inFlight returns the number of bytes held by the payloads of all Builders,
+// which are sized to hold their entire message as soon as they are started. It
+// must be called with bmu held.
+func (r *DefaultReader) inFlight() int {
+	var n int
+	for _, b := range r.builders {
+		n += int(b.Header.MessageHeader.Length)
+	}
+
+	return n
 }
 
+// removeBuilder removes the Builder for the message in progress over the given
+// chunk stream, if any, handing back the payload that it has not built.
 func (r *DefaultReader) removeBuilder(streamId uint32) {
 	r.bmu.Lock()
 	defer r.bmu.Unlock()
 
-	delete(r.builders, streamId)
+	if b := r.builders[streamId]; b != nil {
+		b.Discard()
+		delete(r.builders, streamId)
+	}
 }
@@ -19,6 +19,12 @@ func NewReader(r io.Reader) chunk.Reader {
 	)
 }
 
+// assertChunk asserts that the given chunks hold the same Header and Data.
+func assertChunk(t *testing.T, expected, actual *chunk.Chunk) {
+	assert.Equal(t, expected.Header, actual.Header)
+	assert.Equal(t, expected.Data, actual.Data)
+}
+
 func TestReaderConstruction(t *testing.T) {
 	r := chunk.NewReader(
 		new(bytes.Buffer),
@@ -64,7 +70,7 @@ func TestReadSingleChunkSinglePass(t *testing.T) {
 	read := <-r.Chunks()
 
 	assert.Equal(t, 0, len(r.Errs()))
-	assert.Equal(t, c, read)
+	assertChunk(t, c, read)
 }
 
 func TestReadSingleChunkMultiPass(t *testing.T) {
@@ -86,7 +92,7 @@ func TestReadSingleChunkMultiPass(t *testing.T) {
 	read := <-r.Chunks()
 
 	assert.Equal(t, 0, len(r.Errs()))
-	assert.Equal(t, c, read)
+	assertChunk(t, c, read)
 }
 
 func TestReadMultiChunkSinglePass(t *testing.T) {
@@ -118,8 +124,8 @@ func TestReadMultiChunkSinglePass(t *testing.T) {
 	r2 := <-r.Chunks()
 
 	assert.Equal(t, 0, len(r.Errs()))
-	assert.Equal(t, c1, r1)
-	assert.Equal(t, c2, r2)
+	assertChunk(t, c1, r1)
+	assertChunk(t, c2, r2)
 }
 
 func TestReadMultiChunkMultiPass(t *testing.T) {
@@ -155,6 +161,338 @@ func TestReadMultiChunkMultiPass(t *testing.T) {
 	r2 := <-r.Chunks()
 
 	assert.Equal(t, 0, len(r.Errs()))
-	assert.Equal(t, c1, r1)
-	assert.Equal(t, c2, r2)
+	assertChunk(t, c1, r1)
+	assertChunk(t, c2, r2)
+}
+
+func TestReadContinuationChunksDoNotAdvanceTimestamps(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		6, 0, 0, 10, 0, 0, 8, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Message: 1, Part: 1
+		byte((3 << 6) | 6), 4, 5, 6, 7, // Message: 1, Part: 2
+		byte((2 << 6) | 6), 0, 0, 5, 8, 9, 10, 11, // Message: 2, Part: 1
+		byte((3 << 6) | 6), 12, 13, 14, 15, // Message: 2, Part: 2
+		byte((3 << 6) | 6), 16, 17, 18, 19, // Message: 3, Part: 1
+		byte((3 << 6) | 6), 20, 21, 22, 23, // Message: 3, Part: 2
+	})
+
+	r := chunk.NewReader(buf, 4, chunk.NewNormalizer())
+	go r.Recv()
+
+	for _, ts := range []uint32{10, 15, 20} {
+		c := <-r.Chunks()
+
+		assert.Equal(t, ts, c.Header.Timestamp)
+		assert.Equal(t, uint32(8), c.Header.MessageHeader.Length)
+		assert.Equal(t, byte(9), c.Header.MessageHeader.TypeId)
+	}
+}
+
+func TestReadExtendedTimestampsOnContinuationChunks(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		6, 0xff, 0xff, 0xff, 0, 0, 8, 9, 1, 0, 0, 0, // Message: 1, Part: 1
+		0x01, 0, 0, 0, 0, 1, 2, 3,
+		byte((3 << 6) | 6), 0x01, 0, 0, 0, 4, 5, 6, 7, // Message: 1, Part: 2
+		byte((3 << 6) | 6), 0x01, 0, 0, 0, 8, 9, 10, 11, // Message: 2, Part: 1
+		byte((3 << 6) | 6), 0x01, 0, 0, 0, 12, 13, 14, 15, // Message: 2, Part: 2
+	})
+
+	r := chunk.NewReader(buf, 4, chunk.NewNormalizer())
+	go r.Recv()
+
+	c1 := <-r.Chunks()
+	c2 := <-r.Chunks()
+
+	assert.Equal(t, uint32(0x01000000), c1.Header.Timestamp)
+	assert.Equal(t, []byte{0, 1, 2, 3, 4, 5, 6, 7}, c1.Data)
+	assert.Equal(t, uint32(0x02000000), c2.Header.Timestamp)
+	assert.Equal(t, []byte{8, 9, 10, 11, 12, 13, 14, 15}, c2.Data)
+}
+
+func TestReadAbortMessageDiscardsPartialMessage(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		6, 0, 0, 10, 0, 0, 8, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Message: 1, Part: 1
+		2, 0, 0, 0, 0, 0, 4, 2, 0, 0, 0, 0, 0, 0, 0, 6, // Abort: 6
+		6, 0, 0, 20, 0, 0, 4, 9, 1, 0, 0, 0, 4, 5, 6, 7, // Message: 2
+	})
+
+	r := chunk.NewReader(buf, 4, chunk.NewNormalizer())
+	go r.Recv()
+
+	abort := <-r.Chunks()
+	c := <-r.Chunks()
+
+	assert.Equal(t, byte(2), abort.TypeId())
+	assert.Equal(t, []byte{0, 0, 0, 6}, abort.Data)
+	assert.Equal(t, uint32(20), c.Header.Timestamp)
+	assert.Equal(t, []byte{4, 5, 6, 7}, c.Data)
+}
+
+func TestReadWindowAckSizeSchedulesAcknowledgements(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		2, 0, 0, 0, 0, 0, 4, 5, 0, 0, 0, 0, 0, 0, 0, 20, // WindowAckSize: 20
+		6, 0, 0, 10, 0, 0, 4, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Message: 1
+	})
+
+	r := chunk.NewReader(buf, chunk.DefaultReadSize, chunk.NewNormalizer())
+	go r.Recv()
+
+	window := <-r.Chunks()
+	<-r.Chunks()
+
+	assert.Equal(t, byte(5), window.TypeId())
+	assert.Equal(t, uint32(32), <-r.Acks())
+}
+
+// recordedWindow is a chunk.WindowUpdater which records the type of every
+// chunk that it was updated with.
+type recordedWindow struct {
+	types chan byte
+}
+
+func (w *recordedWindow) UpdateWindow(c *chunk.Chunk) error {
+	w.types <- c.TypeId()
+	return nil
+}
+
+func TestReadHandsFlowControlToTheWindowUpdater(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := chunk.NewWriter(buf, chunk.DefaultReadSize)
+	w.Write(newMuxChunk(2, 0x03, 4))
+	w.Write(newMuxChunk(2, 0x05, 4))
+	w.Write(newMuxChunk(2, 0x06, 5))
+
+	window := &recordedWindow{make(chan byte, 3)}
+
+	r := chunk.NewReader(
+		buf, chunk.DefaultReadSize, chunk.NewNormalizer(),
+	).(*chunk.DefaultReader)
+	r.SetWindowUpdater(window)
+	go r.Recv()
+	defer r.Close()
+
+	// Flow control messages are handed over before they are taken off of
+	// the Chunks() channel, and are still pushed over it.
+	assert.Equal(t, byte(0x03), <-window.types)
+	assert.Equal(t, byte(0x03), (<-r.Chunks()).TypeId())
+	assert.Equal(t, byte(0x05), (<-r.Chunks()).TypeId())
+	assert.Equal(t, byte(0x06), <-window.types)
+	assert.Equal(t, byte(0x06), (<-r.Chunks()).TypeId())
+	assert.Len(t, window.types, 0)
+}
+
+func newLimitedReader(buf *bytes.Buffer, limits chunk.Limits) *chunk.DefaultReader {
+	r := chunk.NewReader(buf, 4, chunk.NewNormalizer()).(*chunk.DefaultReader)
+	r.SetLimits(limits)
+
+	return r
+}
+
+func TestReadRejectsMessagesOverTheMaxMessageSize(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		6, 0, 0, 10, 0, 0, 8, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Length: 8
+	})
+
+	r := newLimitedReader(buf, chunk.Limits{MaxMessageSize: 4})
+	go r.Recv()
+
+	<-r.Done()
+	assert.Equal(t, chunk.ErrMessageTooLarge, r.Err())
+}
+
+func TestReadRejectsTooManyChunkStreams(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		6, 0, 0, 10, 0, 0, 4, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Stream: 6
+		7, 0, 0, 10, 0, 0, 4, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Stream: 7
+		6, 0, 0, 20, 0, 0, 4, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Stream: 6
+		8, 0, 0, 10, 0, 0, 4, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Stream: 8
+	})
+
+	r := newLimitedReader(buf, chunk.Limits{MaxChunkStreamIds: 2})
+	go r.Recv()
+
+	for i := 0; i < 3; i++ {
+		<-r.Chunks()
+	}
+
+	<-r.Done()
+	assert.Equal(t, chunk.ErrTooManyChunkStreams, r.Err())
+}
+
+func TestReadRejectsCompressedHeadersOnUnknownChunkStreams(t *testing.T) {
+	for _, header := range [][]byte{
+		{0x46, 0, 0, 10, 0, 0, 4, 9}, // Type 1
+		{0x86, 0, 0, 10},             // Type 2
+		{0xc6},                       // Type 3
+	} {
+		buf := bytes.NewBuffer(header)
+		buf.Write([]byte{0, 1, 2, 3})
+
+		r := newLimitedReader(buf, chunk.DefaultLimits)
+		go r.Recv()
+
+		<-r.Done()
+		assert.Equal(t, chunk.ErrUnknownChunkStream, r.Err())
+	}
+}
+
+func TestReadRejectsChunkSizesOverTheMaxChunkSize(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		2, 0, 0, 0, 0, 0, 4, 1, 0, 0, 0, 0, 0, 0, 1, 0, // SetChunkSize: 256
+	})
+
+	r := newLimitedReader(buf, chunk.Limits{MaxChunkSize: 128})
+	go r.Recv()
+
+	<-r.Done()
+	assert.Equal(t, chunk.ErrChunkSizeTooLarge, r.Err())
+	assert.Equal(t, 4, r.ReadSize())
+}
+
+func TestReadRejectsChunkSizesOfZero(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		2, 0, 0, 0, 0, 0, 4, 1, 0, 0, 0, 0, 0, 0, 0, 0, // SetChunkSize: 0
+	})
+
+	r := newLimitedReader(buf, chunk.Limits{})
+	go r.Recv()
+
+	<-r.Done()
+	assert.Equal(t, chunk.ErrInvalidChunkSize, r.Err())
+}
+
+func TestReadRejectsTooManyBytesInFlight(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		6, 0, 0, 10, 0, 0, 8, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Message: 1, Part: 1
+		7, 0, 0, 10, 0, 0, 8, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Message: 2, Part: 1
+		8, 0, 0, 10, 0, 0, 8, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Message: 3, Part: 1
+	})
+
+	r := newLimitedReader(buf, chunk.Limits{MaxBytesInFlight: 8})
+	go r.Recv()
+
+	<-r.Done()
+	assert.Equal(t, chunk.ErrTooManyBytesInFlight, r.Err())
+}
+
+func TestReadReleasesBytesInFlightOfCompleteMessages(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		6, 0, 0, 10, 0, 0, 8, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Message: 1, Part: 1
+		byte((3 << 6) | 6), 4, 5, 6, 7, // Message: 1, Part: 2
+		6, 0, 0, 20, 0, 0, 8, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Message: 2, Part: 1
+		byte((3 << 6) | 6), 4, 5, 6, 7, // Message: 2, Part: 2
+	})
+
+	r := newLimitedReader(buf, chunk.Limits{MaxBytesInFlight: 8})
+	go r.Recv()
+
+	<-r.Chunks()
+	c := <-r.Chunks()
+
+	assert.Equal(t, uint32(20), c.Header.Timestamp)
+}
+
+func TestReadStopsAtEOF(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		6, 0, 0, 10, 0, 0, 4, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Message: 1
+	})
+
+	r := NewReader(buf)
+	go r.Recv()
+
+	<-r.Chunks()
+	<-r.Done()
+
+	assert.Equal(t, io.EOF, r.Err())
+	r.Close()
 }
+
+func TestReadCloseStopsAPendingPush(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		6, 0, 0, 10, 0, 0, 4, 9, 1, 0, 0, 0, 0, 1, 2, 3, // Message: 1
+	})
+
+	r := NewReader(buf)
+	go r.Recv()
+
+	r.Close()
+	<-r.Done()
+
+	assert.Nil(t, r.Err())
+}
+
+func TestReadMessageReadsMessagesSynchronously(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{
+		2, 0, 0, 0, 0, 0, 4, 1, 0, 0, 0, 0, 0, 0, 0, 8, // SetChunkSize: 8
+		2, 0, 0, 0, 0, 0, 4, 5, 0, 0, 0, 0, 0, 0, 0, 20, // WindowAckSize: 20
+		6, 0, 0, 10, 0, 0, 8, 9, 1, 0, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, // Message: 1
+	})
+
+	r := chunk.NewReader(buf, 4, chunk.NewNormalizer()).(*chunk.DefaultReader)
+
+	window, err := r.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, byte(5), window.TypeId())
+
+	c, err := r.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0, 1, 2, 3, 4, 5, 6, 7}, c.Data)
+	assert.Equal(t, uint32(52), <-r.Acks())
+
+	_, err = r.ReadMessage()
+	assert.Equal(t, io.EOF, err)
+	assert.Nil(t, r.Err())
+}
+
+// loopReader is an io.Reader which reads the same bytes over and over again.
+type loopReader struct {
+	data []byte
+	off  int
+}
+
+func (l *loopReader) Read(p []byte) (int, error) {
+	n := copy(p, l.data[l.off:])
+	l.off = (l.off + n) % len(l.data)
+
+	return n, nil
+}
+
+func benchmarkReadMessage(b *testing.B, release bool) {
+	msg := new(bytes.Buffer)
+	chunk.NewWriter(msg, 4096).Write(newMuxChunk(6, 9, 64*1024))
+
+	r := chunk.NewReader(
+		&loopReader{data: msg.Bytes()}, 4096, chunk.NewNormalizer(),
+	).(*chunk.DefaultReader)
+
+	b.ReportAllocs()
+	b.SetBytes(64 * 1024)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c, err := r.ReadMessage()
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if release {
+			c.Release()
+		}
+	}
+}
+
+func BenchmarkReadMessage(b *testing.B) { benchmarkReadMessage(b, false) }
+
+func BenchmarkReadMessageReleased(b *testing.B) { benchmarkReadMessage(b, true) }
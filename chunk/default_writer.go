@@ -3,21 +3,60 @@ package chunk
 import (
 	"bytes"
 	"io"
+	"net"
 	"sync"
 
 	"github.com/WatchBeam/rtmp/spec"
 )
 
 // DefaultWriter provides a default implementation of chunk.Writer interface.
+//
+// Outgoing message headers are compressed against the last header that was
+// written over the same chunk stream, such that the smallest possible header
+// format (0, 1, 2 or 3) is chosen for each message, as described in section
+// 5.3.1.2 of the RTMP specification. This is the write-side counterpart to the
+// DefaultNormalizer.
+//
+// A DefaultWriter is safe to share between goroutines, though each message is
+// written as a whole. See MuxWriter for a Writer which interleaves them.
 type DefaultWriter struct {
 	// dest is the io.Writer where chunks are written to.
 	dest io.Writer
+	// dmu serializes writes to dest, such that chunks written from
+	// multiple goroutines are not interleaved, and the write size does not
+	// change while a chunk is being split.
+	dmu sync.Mutex
 
 	// wmu guards writeSize.
 	wmu sync.Mutex
 	// writeSize is the maximum payload length of a single chunk that can
 	// be written without haveing to write multiple chunks.
 	writeSize int
+
+	// nmu guards written.
+	nmu sync.Mutex
+	// written is the total number of bytes written to dest, modulo 2^32.
+	written uint32
+
+	// hmu guards headers.
+	hmu sync.Mutex
+	// headers maps chunk stream IDs (found in the basic header) to the
+	// state of the last message header written over that chunk stream.
+	headers map[uint32]*sentHeader
+}
+
+// sentHeader holds the state of the last message header written over a single
+// chunk stream.
+type sentHeader struct {
+	// header is the complete message header of the last message, with an
+	// absolute timestamp.
+	header MessageHeader
+	// delta is the last timestamp delta that was written, valid only when
+	// hasDelta is true.
+	delta uint32
+	// hasDelta is true when the last header written was a type 1, 2 or 3
+	// header, meaning that a type 3 header may re-use its delta.
+	hasDelta bool
 }
 
 var _ Writer = new(DefaultWriter)
@@ -32,32 +71,194 @@ func (w *DefaultWriter) WriteSize() int {
 
 // SetWriteSize implements the SetWriteSize function defined in the Writer interface.
 func (w *DefaultWriter) SetWriteSize(writeSize int) {
+	if writeSize < 1 {
+		return
+	}
+
 	w.wmu.Lock()
 	defer w.wmu.Unlock()
 
 	w.writeSize = writeSize
 }
 
-// Write implements the Write function defined in the Writer interface.
+// Write implements the Write function defined in the Writer interface. The
+// FormatId of the given chunk's header is ignored, and its MessageHeader is
+// treated as a complete header with an absolute timestamp (or, if it holds a
+// timestamp delta, the absolute timestamp is taken from the Header's Timestamp
+// field, as filled in by a Normalizer). The header that is actually written is
+// compressed against the previous message sent over the same chunk stream (see
+// Compress).
 func (w *DefaultWriter) Write(c *Chunk) error {
+	w.dmu.Lock()
+	defer w.dmu.Unlock()
+
+	return w.write(c)
+}
+
+// Resize writes the given chunk, and then changes the write size to `size`
+// before any other chunk may be written. It is used to send a Set Chunk Size
+// control sequence, since the peer expects every chunk following it (and none
+// before it) to be split according to the new size.
+func (w *DefaultWriter) Resize(c *Chunk, size int) error {
+	w.dmu.Lock()
+	defer w.dmu.Unlock()
+
+	if err := w.write(c); err != nil {
+		return err
+	}
+
+	w.SetWriteSize(size)
+
+	return nil
+}
+
+// write implements the Write function. It must be called with dmu held.
+func (w *DefaultWriter) write(c *Chunk) error {
 	payload := bytes.NewBuffer(c.Data)
 	out := new(bytes.Buffer)
 
-	c.Header.Write(out)
+	header := w.Compress(c.Header)
+	if err := header.Write(out); err != nil {
+		return err
+	}
+
+	cont := continuation(header)
 	for payload.Len() > 0 {
 		io.CopyN(out, payload, int64(spec.Min(payload.Len(),
 			w.WriteSize())))
 
 		if payload.Len() > 0 {
-			out.Write([]byte{byte(
-				(3 << 6) | (c.Header.BasicHeader.StreamId & 63)),
-			})
+			if err := cont.Write(out); err != nil {
+				return err
+			}
 		}
 	}
 
-	if _, err := io.Copy(w.dest, out); err != nil {
-		return err
+	return w.writeRaw(out.Bytes())
+}
+
+// writeRaw writes the given bytes to dest, counting them towards the number of
+// bytes written.
+func (w *DefaultWriter) writeRaw(p []byte) error {
+	n, err := w.dest.Write(p)
+	w.addWritten(int64(n))
+
+	return err
+}
+
+// writeBuffers writes the given buffers to dest with a single write, and counts
+// them towards the number of bytes written. See WriteBuffers.
+func (w *DefaultWriter) writeBuffers(bufs net.Buffers) error {
+	n, err := WriteBuffers(w.dest, bufs)
+	w.addWritten(n)
+
+	return err
+}
+
+// continuation returns the type 3 Header that precedes every chunk of a message
+// but the first, given the Header of its first chunk. Continuation chunks
+// repeat the ExtendedTimestamp of the first chunk, if it had one.
+func continuation(header *Header) *Header {
+	return &Header{
+		BasicHeader: BasicHeader{
+			FormatId: 3,
+			StreamId: header.BasicHeader.StreamId,
+		},
+		MessageHeader: MessageHeader{
+			FormatId:  3,
+			Timestamp: header.MessageHeader.Timestamp,
+		},
+		ExtendedTimestamp: header.ExtendedTimestamp,
 	}
+}
 
-	return nil
+// BytesWritten returns the total number of bytes written by this DefaultWriter,
+// modulo 2^32. This is the same quantity that the peer acknowledges with the
+// sequence number of an Acknowledgement control sequence.
+func (w *DefaultWriter) BytesWritten() uint32 {
+	w.nmu.Lock()
+	defer w.nmu.Unlock()
+
+	return w.written
+}
+
+// addWritten adds `n` bytes to the number of bytes written.
+func (w *DefaultWriter) addWritten(n int64) {
+	w.nmu.Lock()
+	defer w.nmu.Unlock()
+
+	w.written += uint32(n)
+}
+
+// Compress returns the Header that should be written in order to send a
+// message with the given complete header, and records it as the last header
+// sent over its chunk stream. The format is chosen as follows:
+//
+//   - Type 0, if nothing has been sent over the chunk stream yet, the message
+//     stream ID has changed, or the timestamp has moved backwards.
+//   - Type 1, if the length or type ID of the message has changed.
+//   - Type 2, if only the timestamp delta has changed.
+//   - Type 3, if the header is identical to the previous one, including the
+//     timestamp delta.
+//
+// A type 3 header is never chosen directly after a type 0 header, since peers
+// disagree about which delta that implies. Timestamps and deltas that do not
+// fit in 24 bits are moved into the ExtendedTimestamp of the returned Header.
+func (w *DefaultWriter) Compress(h *Header) *Header {
+	w.hmu.Lock()
+	defer w.hmu.Unlock()
+
+	if w.headers == nil {
+		w.headers = make(map[uint32]*sentHeader)
+	}
+
+	m := h.MessageHeader
+	m.Timestamp = h.TimestampField()
+	if m.TimestampDelta {
+		m.Timestamp = h.Timestamp
+		m.TimestampDelta = false
+	}
+
+	out := &Header{
+		BasicHeader:   BasicHeader{StreamId: h.BasicHeader.StreamId},
+		MessageHeader: m,
+	}
+
+	last := w.headers[h.BasicHeader.StreamId]
+	next := &sentHeader{header: m}
+
+	switch {
+	case last == nil,
+		m.StreamId != last.header.StreamId,
+		m.Timestamp < last.header.Timestamp:
+
+		out.BasicHeader.FormatId = 0
+	default:
+		next.delta = m.Timestamp - last.header.Timestamp
+		next.hasDelta = true
+
+		switch {
+		case m.Length != last.header.Length,
+			m.TypeId != last.header.TypeId:
+
+			out.BasicHeader.FormatId = 1
+		case !last.hasDelta, next.delta != last.delta:
+			out.BasicHeader.FormatId = 2
+		default:
+			out.BasicHeader.FormatId = 3
+		}
+
+		out.MessageHeader.Timestamp = next.delta
+		out.MessageHeader.TimestampDelta = true
+	}
+
+	if out.MessageHeader.HasExtendedTimestamp() {
+		out.ExtendedTimestamp.Delta = out.MessageHeader.Timestamp
+		out.MessageHeader.Timestamp = MaxTimestamp
+	}
+
+	out.MessageHeader.FormatId = out.BasicHeader.FormatId
+	w.headers[h.BasicHeader.StreamId] = next
+
+	return out
 }
@@ -66,3 +66,255 @@ func TestMultipleWrites(t *testing.T) {
 		fmt.Sprintf("test: slice should be equal (%v, %v)", expected.Bytes(),
 			buf.Bytes()))
 }
+
+func TestWriterCompressesHeaders(t *testing.T) {
+	w := chunk.NewWriter(new(bytes.Buffer), chunk.DefaultReadSize).(*chunk.DefaultWriter)
+
+	for _, tc := range []struct {
+		In     chunk.MessageHeader
+		Format byte
+		Delta  uint32
+	}{
+		{chunk.MessageHeader{0, 1000, false, 8, 9, 1}, 0, 1000},
+		{chunk.MessageHeader{0, 1040, false, 8, 9, 1}, 2, 40},
+		{chunk.MessageHeader{0, 1080, false, 8, 9, 1}, 3, 40},
+		{chunk.MessageHeader{0, 1120, false, 9, 9, 1}, 1, 40},
+		{chunk.MessageHeader{0, 1150, false, 9, 9, 1}, 2, 30},
+		{chunk.MessageHeader{0, 1180, false, 9, 8, 1}, 1, 30},
+		{chunk.MessageHeader{0, 1180, false, 9, 8, 2}, 0, 1180},
+		{chunk.MessageHeader{0, 1100, false, 9, 8, 2}, 0, 1100},
+	} {
+		h := w.Compress(&chunk.Header{
+			BasicHeader:   chunk.BasicHeader{0, 6},
+			MessageHeader: tc.In,
+		})
+
+		assert.Equal(t, tc.Format, h.BasicHeader.FormatId)
+		assert.Equal(t, tc.Format, h.MessageHeader.FormatId)
+		assert.Equal(t, uint32(6), h.BasicHeader.StreamId)
+		assert.Equal(t, tc.Delta, h.MessageHeader.Timestamp)
+		assert.Equal(t, tc.Format != 0, h.MessageHeader.TimestampDelta)
+	}
+}
+
+func TestWriterCompressesEachChunkStreamSeparately(t *testing.T) {
+	w := chunk.NewWriter(new(bytes.Buffer), chunk.DefaultReadSize).(*chunk.DefaultWriter)
+
+	a := w.Compress(&chunk.Header{
+		BasicHeader:   chunk.BasicHeader{0, 6},
+		MessageHeader: chunk.MessageHeader{0, 10, false, 8, 9, 1},
+	})
+	b := w.Compress(&chunk.Header{
+		BasicHeader:   chunk.BasicHeader{0, 7},
+		MessageHeader: chunk.MessageHeader{0, 10, false, 8, 9, 1},
+	})
+
+	assert.Equal(t, byte(0), a.BasicHeader.FormatId)
+	assert.Equal(t, byte(0), b.BasicHeader.FormatId)
+}
+
+func TestWriterDoesNotModifyGivenHeader(t *testing.T) {
+	w := chunk.NewWriter(new(bytes.Buffer), chunk.DefaultReadSize)
+	h := &chunk.Header{
+		BasicHeader:   chunk.BasicHeader{0, 6},
+		MessageHeader: chunk.MessageHeader{0, 10, false, 1, 9, 1},
+	}
+
+	w.Write(&chunk.Chunk{Header: h, Data: []byte{0}})
+	h.MessageHeader.Timestamp = 20
+	w.Write(&chunk.Chunk{Header: h, Data: []byte{0}})
+
+	assert.Equal(t, chunk.MessageHeader{0, 20, false, 1, 9, 1}, h.MessageHeader)
+}
+
+func TestCompressedWritesAreSmaller(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := chunk.NewWriter(buf, chunk.DefaultReadSize)
+
+	for i := 0; i < 3; i++ {
+		w.Write(&chunk.Chunk{
+			Header: &chunk.Header{
+				BasicHeader: chunk.BasicHeader{0, 6},
+				MessageHeader: chunk.MessageHeader{
+					0, uint32(i * 40), false, 2, 9, 1,
+				},
+			},
+			Data: []byte{0, 1},
+		})
+	}
+
+	// (1 + 11 + 2) + (1 + 3 + 2) + (1 + 2)
+	assert.Equal(t, 23, buf.Len())
+}
+
+func TestCompressedWritesRoundTrip(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := chunk.NewWriter(buf, 4)
+
+	sent := []*chunk.Chunk{
+		{
+			Header: &chunk.Header{
+				BasicHeader:   chunk.BasicHeader{0, 6},
+				MessageHeader: chunk.MessageHeader{0, 100, false, 6, 9, 1},
+			},
+			Data: []byte{0, 1, 2, 3, 4, 5},
+		},
+		{
+			Header: &chunk.Header{
+				BasicHeader:   chunk.BasicHeader{0, 6},
+				MessageHeader: chunk.MessageHeader{0, 133, false, 6, 9, 1},
+			},
+			Data: []byte{6, 7, 8, 9, 10, 11},
+		},
+		{
+			Header: &chunk.Header{
+				BasicHeader:   chunk.BasicHeader{0, 6},
+				MessageHeader: chunk.MessageHeader{0, 166, false, 6, 9, 1},
+			},
+			Data: []byte{12, 13, 14, 15, 16, 17},
+		},
+		{
+			Header: &chunk.Header{
+				BasicHeader:   chunk.BasicHeader{0, 6},
+				MessageHeader: chunk.MessageHeader{0, 200, false, 3, 8, 1},
+			},
+			Data: []byte{18, 19, 20},
+		},
+	}
+
+	for _, c := range sent {
+		assert.Nil(t, w.Write(c))
+	}
+
+	r := chunk.NewReader(buf, 4, chunk.NewNormalizer())
+	go r.Recv()
+
+	for i, c := range sent {
+		read := <-r.Chunks()
+
+		assert.Equal(t, c.Data, read.Data)
+		assert.Equal(t, c.Header.MessageHeader.Length,
+			read.Header.MessageHeader.Length)
+		assert.Equal(t, c.Header.MessageHeader.TypeId,
+			read.Header.MessageHeader.TypeId)
+		assert.Equal(t, c.Header.MessageHeader.StreamId,
+			read.Header.MessageHeader.StreamId)
+
+		assert.Equal(t, c.Header.MessageHeader.Timestamp,
+			read.Header.Timestamp)
+
+		if i > 0 {
+			assert.True(t, read.Header.MessageHeader.TimestampDelta)
+			assert.Equal(t, c.Header.MessageHeader.Timestamp-
+				sent[i-1].Header.MessageHeader.Timestamp,
+				read.Header.MessageHeader.Timestamp)
+		}
+	}
+}
+
+func TestWriterUsesAbsoluteTimestampOfRelativeHeaders(t *testing.T) {
+	w := chunk.NewWriter(new(bytes.Buffer), chunk.DefaultReadSize).(*chunk.DefaultWriter)
+
+	h := w.Compress(&chunk.Header{
+		BasicHeader:   chunk.BasicHeader{2, 6},
+		MessageHeader: chunk.MessageHeader{2, 40, true, 8, 9, 1},
+		Timestamp:     1040,
+	})
+
+	assert.Equal(t, byte(0), h.BasicHeader.FormatId)
+	assert.Equal(t, uint32(1040), h.MessageHeader.Timestamp)
+	assert.False(t, h.MessageHeader.TimestampDelta)
+}
+
+func TestWriterRepeatsExtendedTimestampsOnContinuationChunks(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := chunk.NewWriter(buf, 4).Write(&chunk.Chunk{
+		Header: &chunk.Header{
+			BasicHeader:   chunk.BasicHeader{0, 6},
+			MessageHeader: chunk.MessageHeader{0, 0x01000000, false, 8, 9, 1},
+		},
+		Data: []byte{0, 1, 2, 3, 4, 5, 6, 7},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{
+		6, 0xff, 0xff, 0xff, 0, 0, 8, 9, 1, 0, 0, 0,
+		0x01, 0, 0, 0, 0, 1, 2, 3,
+		byte((3 << 6) | 6), 0x01, 0, 0, 0, 4, 5, 6, 7,
+	}, buf.Bytes())
+}
+
+func TestExtendedTimestampsRoundTrip(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := chunk.NewWriter(buf, 4)
+
+	timestamps := []uint32{
+		0xfffffe, 0xffffff, 0x1000000, 0x2000001, 0x3000002,
+		0xfffffff0, 0x20,
+	}
+
+	for _, ts := range timestamps {
+		assert.Nil(t, w.Write(&chunk.Chunk{
+			Header: &chunk.Header{
+				BasicHeader:   chunk.BasicHeader{0, 6},
+				MessageHeader: chunk.MessageHeader{0, ts, false, 6, 9, 1},
+			},
+			Data: []byte{0, 1, 2, 3, 4, 5},
+		}))
+	}
+
+	r := chunk.NewReader(buf, 4, chunk.NewNormalizer())
+	go r.Recv()
+
+	for _, ts := range timestamps {
+		c := <-r.Chunks()
+
+		assert.Equal(t, ts, c.Header.Timestamp)
+		assert.Equal(t, []byte{0, 1, 2, 3, 4, 5}, c.Data)
+	}
+}
+
+func TestWriterCountsBytesWritten(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := chunk.NewWriter(buf, 4).(*chunk.DefaultWriter)
+
+	for i := 0; i < 2; i++ {
+		w.Write(&chunk.Chunk{
+			Header: &chunk.Header{
+				BasicHeader:   chunk.BasicHeader{0, 6},
+				MessageHeader: chunk.MessageHeader{0, 0, false, 6, 9, 1},
+			},
+			Data: []byte{0, 1, 2, 3, 4, 5},
+		})
+	}
+
+	assert.Equal(t, uint32(buf.Len()), w.BytesWritten())
+}
+
+func TestResizeChangesTheWriteSizeAfterWriting(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := chunk.NewWriter(buf, 4).(*chunk.DefaultWriter)
+
+	err := w.Resize(&chunk.Chunk{
+		Header: &chunk.Header{
+			BasicHeader:   chunk.BasicHeader{0, 2},
+			MessageHeader: chunk.MessageHeader{0, 0, false, 6, 1, 0},
+		},
+		Data: []byte{0, 1, 2, 3, 4, 5},
+	}, 128)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 128, w.WriteSize())
+	// One type 0 header, the first 4 bytes, a type 3 header, and the
+	// remaining 2 bytes.
+	assert.Equal(t, 12+4+1+2, buf.Len())
+}
+
+func TestSetWriteSizeIgnoresSizesBelowOne(t *testing.T) {
+	w := chunk.NewWriter(new(bytes.Buffer), chunk.DefaultReadSize)
+
+	w.SetWriteSize(0)
+	w.SetWriteSize(-1)
+
+	assert.Equal(t, chunk.DefaultReadSize, w.WriteSize())
+}
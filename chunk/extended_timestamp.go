@@ -11,7 +11,7 @@ import (
 // delta. It will only be present when the timestamp field of a type 0 header,
 // or the timestamp delta field of a type 1 or 2 header is set to 0xffffff.
 // Additionally, this field is present in type 3 chunks when the last type 0, 1
-// or 2 chunk indicated presence of this field.
+// or 2 chunk indicated presence of this field (see Header.ReadExtendedTimestamp).
 type ExtendedTimestamp struct {
 	// Delta encodes the complete timestamp or extended timestamp field for
 	// chunks matching the scenario as described above.
@@ -11,12 +11,22 @@ type Header struct {
 	MessageHeader MessageHeader
 	// ExtendedTimestamp is the RTMP ExtendedTimestamp of the given Header.
 	ExtendedTimestamp ExtendedTimestamp
+
+	// Timestamp is the absolute timestamp of the message that this Header
+	// belongs to, reconstructed by the Normalizer from the (possibly
+	// relative) timestamp in the MessageHeader. It is neither read from,
+	// nor written to the wire.
+	Timestamp uint32
 }
 
 // Read reads a Header (partial or complete) from the given io.Reader, by
 // delegating into the Read methods of each component part of this header. If
 // any error is encountered during the read, then execution will be halted, and
 // the error will be returned immediately.
+//
+// Type 3 headers carry an ExtendedTimestamp only when the last type 0, 1 or 2
+// header over the same chunk stream did, which Read has no way of knowing. See
+// ReadExtendedTimestamp.
 func (h *Header) Read(r io.Reader) error {
 	if err := h.BasicHeader.Read(r); err != nil {
 		return err
@@ -40,6 +50,10 @@ func (h *Header) Read(r io.Reader) error {
 // into each of the component parts of the header. If any error is returned from
 // the individual writes, then it will be returned immediately, and the Header
 // CAN NOT be considered to be fully written.
+//
+// If the timestamp field of the MessageHeader is larger than MaxTimestamp, it
+// is written as an ExtendedTimestamp transparently. If it is equal to
+// MaxTimestamp, the ExtendedTimestamp is written as-is.
 func (h *Header) Write(w io.Writer) error {
 	if err := h.BasicHeader.Write(w); err != nil {
 		return err
@@ -49,11 +63,41 @@ func (h *Header) Write(w io.Writer) error {
 		return err
 	}
 
-	if h.MessageHeader.Timestamp == 0xffffff {
-		if err := h.ExtendedTimestamp.Write(w); err != nil {
+	if h.MessageHeader.HasExtendedTimestamp() {
+		ext := &ExtendedTimestamp{Delta: h.TimestampField()}
+		if err := ext.Write(w); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// TimestampField returns the complete, 32-bit value of the timestamp (or
+// timestamp delta) field of this Header, taking the ExtendedTimestamp into
+// account when it is present. Since the ExtendedTimestamp is only ever used to
+// encode values of at least MaxTimestamp, a timestamp field of MaxTimestamp
+// without one is taken to be the value itself.
+func (h *Header) TimestampField() uint32 {
+	if h.MessageHeader.Timestamp == MaxTimestamp &&
+		h.ExtendedTimestamp.Delta >= MaxTimestamp {
+
+		return h.ExtendedTimestamp.Delta
+	}
+
+	return h.MessageHeader.Timestamp
+}
+
+// ReadExtendedTimestamp reads the ExtendedTimestamp of a type 3 Header from the
+// given io.Reader, marking the timestamp field of the MessageHeader as
+// extended. It should be called after Read when the last type 0, 1 or 2 header
+// over the same chunk stream had an ExtendedTimestamp.
+func (h *Header) ReadExtendedTimestamp(r io.Reader) error {
+	if err := h.ExtendedTimestamp.Read(r); err != nil {
+		return err
+	}
+
+	h.MessageHeader.Timestamp = MaxTimestamp
+
+	return nil
+}
@@ -35,3 +35,50 @@ func TestHeaderReading(t *testing.T) {
 	assert.Equal(t, uint32(0xffffff), h.MessageHeader.Timestamp)
 	assert.Equal(t, uint32(1234), h.ExtendedTimestamp.Delta)
 }
+
+func TestHeaderWritingExtendsLargeTimestamps(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h := &chunk.Header{
+		BasicHeader: chunk.BasicHeader{2, 6},
+		MessageHeader: chunk.MessageHeader{
+			FormatId:  2,
+			Timestamp: 0x12345678,
+		},
+	}
+
+	err := h.Write(buf)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{
+		(0x02 << 6) | 6,
+		0xff, 0xff, 0xff,
+		0x12, 0x34, 0x56, 0x78,
+	}, buf.Bytes())
+}
+
+func TestHeaderTimestampFieldUsesExtendedTimestamp(t *testing.T) {
+	h := &chunk.Header{
+		MessageHeader:     chunk.MessageHeader{Timestamp: 0xffffff},
+		ExtendedTimestamp: chunk.ExtendedTimestamp{0x12345678},
+	}
+
+	assert.Equal(t, uint32(0x12345678), h.TimestampField())
+
+	h.MessageHeader.Timestamp = 1234
+
+	assert.Equal(t, uint32(1234), h.TimestampField())
+}
+
+func TestHeaderReadsExtendedTimestampOfTypeThreeHeaders(t *testing.T) {
+	h := &chunk.Header{}
+	buf := bytes.NewBuffer([]byte{
+		(0x03 << 6) | 6,
+		0x12, 0x34, 0x56, 0x78,
+	})
+
+	assert.Nil(t, h.Read(buf))
+	assert.Nil(t, h.ReadExtendedTimestamp(buf))
+
+	assert.Equal(t, uint32(0x12345678), h.TimestampField())
+	assert.Equal(t, 0, buf.Len())
+}
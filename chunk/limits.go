@@ -0,0 +1,56 @@
+package chunk
+
+import "errors"
+
+var (
+	// ErrMessageTooLarge is returned when the peer sends a message longer
+	// than Limits.MaxMessageSize.
+	ErrMessageTooLarge = errors.New("rtmp: message too large")
+	// ErrTooManyChunkStreams is returned when the peer uses more chunk
+	// stream IDs than Limits.MaxChunkStreamIds.
+	ErrTooManyChunkStreams = errors.New("rtmp: too many chunk streams")
+	// ErrChunkSizeTooLarge is returned when the peer sets a chunk size
+	// larger than Limits.MaxChunkSize.
+	ErrChunkSizeTooLarge = errors.New("rtmp: chunk size too large")
+	// ErrInvalidChunkSize is returned when the peer sends a Set Chunk Size
+	// message that does not hold a chunk size of at least one byte.
+	ErrInvalidChunkSize = errors.New("rtmp: invalid chunk size")
+	// ErrTooManyBytesInFlight is returned when the peer sends more bytes of
+	// partial messages than Limits.MaxBytesInFlight.
+	ErrTooManyBytesInFlight = errors.New("rtmp: too many bytes in flight")
+)
+
+// Limits bounds the resources that a DefaultReader uses on behalf of the peer
+// that it is reading from. A limit of zero means that there is no limit.
+//
+// Once a limit has been exceeded, the chunk stream can not be trusted (or, in
+// most cases, even parsed) any further: the DefaultReader stops reading, and
+// the corresponding error is returned by its Err function. The connection
+// should be closed as well.
+type Limits struct {
+	// MaxMessageSize is the maximum length of a single message, in bytes.
+	MaxMessageSize uint32
+	// MaxChunkStreamIds is the maximum number of distinct chunk stream IDs
+	// that the peer may use over the lifetime of the connection. IDs are
+	// never freed, even once no message is in progress over them, since
+	// the state of every chunk stream (such as the last header read from
+	// it) is kept for as long as the connection is open.
+	MaxChunkStreamIds int
+	// MaxChunkSize is the maximum chunk size that the peer may set with a
+	// Set Chunk Size message.
+	MaxChunkSize uint32
+	// MaxBytesInFlight is the maximum number of bytes that may be held in
+	// partially read messages, across all chunk streams. Since the payload
+	// of a message is allocated in full once its first chunk is read, every
+	// partially read message counts towards it with its entire length.
+	MaxBytesInFlight int
+}
+
+// DefaultLimits are the Limits that a DefaultReader is constructed with. They
+// leave plenty of room for well-behaved peers.
+var DefaultLimits = Limits{
+	MaxMessageSize:    8 << 20,
+	MaxChunkStreamIds: 64,
+	MaxChunkSize:      1 << 16,
+	MaxBytesInFlight:  32 << 20,
+}
@@ -15,6 +15,14 @@ var (
 	ErrUnknownFormatId = errors.New("rtmp: unknown message header ID")
 )
 
+const (
+	// MaxTimestamp is the largest value that can be held in the 24-bit
+	// timestamp field of a MessageHeader. When the timestamp field holds
+	// this value, the complete timestamp (or delta) is encoded in the
+	// ExtendedTimestamp instead.
+	MaxTimestamp uint32 = 0xffffff
+)
+
 // MessageHeader represents the MessageHeader component of a chunk Header, as
 // defined in the RTMP specification.
 type MessageHeader struct {
@@ -40,7 +48,7 @@ type MessageHeader struct {
 // HasExtendedTimestamp determines whether or not an ExtendedTimestamp header is
 // necessary to encode the full timestamp.
 func (m *MessageHeader) HasExtendedTimestamp() bool {
-	return m.Timestamp == 0xffffff
+	return m.Timestamp >= MaxTimestamp
 }
 
 // Read reads a type 0, 1, 2, or 3-format MessageHeader from the given
@@ -87,21 +95,29 @@ func (m *MessageHeader) Read(r io.Reader) error {
 
 // Write encodes and writes the data held by this MessageHeader to the given
 // io.Writer, returning any error encountered during the write as it occurs.
+//
+// Timestamps that do not fit in 24 bits are written as MaxTimestamp, and must
+// be followed by an ExtendedTimestamp (see Header.Write).
 func (m *MessageHeader) Write(w io.Writer) error {
 	buf := new(bytes.Buffer)
 
+	timestamp := m.Timestamp
+	if m.HasExtendedTimestamp() {
+		timestamp = MaxTimestamp
+	}
+
 	switch m.FormatId {
 	case 0:
-		spec.PutUint24(m.Timestamp, buf)
+		spec.PutUint24(timestamp, buf)
 		spec.PutUint24(m.Length, buf)
 		spec.PutUint8(m.TypeId, buf)
 		spec.LittleEndianPutUint32(m.StreamId, buf)
 	case 1:
-		spec.PutUint24(m.Timestamp, buf)
+		spec.PutUint24(timestamp, buf)
 		spec.PutUint24(m.Length, buf)
 		spec.PutUint8(m.TypeId, buf)
 	case 2:
-		spec.PutUint24(m.Timestamp, buf)
+		spec.PutUint24(timestamp, buf)
 	}
 
 	if _, err := w.Write(buf.Bytes()); err != nil {
@@ -5,12 +5,18 @@ import "sync"
 // MultiStream represents a concatenation of multiple other chunk.Streams. It
 // implements the Stream interface by providing an In() channel that is fed from
 // all other `Append`-ed Streans by using the fan-in pattern.
+//
+// Chunks coming from different Streams are not delivered in any particular
+// order. See OrderedStream for a Stream that preserves the order of multiple
+// chunk streams read by a Parser.
 type MultiStream struct {
 	// wg waits on the number of active sources still open until that
 	// number is zero.
 	wg sync.WaitGroup
 	// out is the sink of all active sources.
 	out chan *Chunk
+	// once ensures that out is closed only once.
+	once sync.Once
 }
 
 var _ Stream = new(MultiStream)
@@ -47,9 +53,9 @@ func (m *MultiStream) Append(others ...Stream) *MultiStream {
 }
 
 // AwaitClose awaits until all feeding goroutines have closed themselves, and
-// then closes our own internal out channel.
+// then closes our own internal out channel. It may be called more than once.
 func (m *MultiStream) AwaitClose() {
 	m.wg.Wait()
 
-	close(m.out)
+	m.once.Do(func() { close(m.out) })
 }
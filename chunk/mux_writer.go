@@ -0,0 +1,357 @@
+package chunk
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/WatchBeam/rtmp/spec"
+)
+
+// Priority determines the order in which the chunks of messages written to a
+// MuxWriter are sent. Lower values are sent first.
+type Priority byte
+
+const (
+	// PriorityControl is the priority of protocol control messages (type
+	// IDs 1 through 6).
+	PriorityControl Priority = iota
+	// PriorityCommand is the priority of all messages that are not control
+	// messages, audio or video, such as commands and data messages.
+	PriorityCommand
+	// PriorityAudio is the priority of audio messages (type ID 8).
+	PriorityAudio
+	// PriorityVideo is the priority of video messages (type ID 9).
+	PriorityVideo
+)
+
+// PriorityOf returns the Priority of the message carried by the given chunk,
+// based on its type ID.
+func PriorityOf(c *Chunk) Priority {
+	switch id := c.TypeId(); {
+	case id >= 0x01 && id <= 0x06:
+		return PriorityControl
+	case id == 0x08:
+		return PriorityAudio
+	case id == 0x09:
+		return PriorityVideo
+	default:
+		return PriorityCommand
+	}
+}
+
+// FlushPolicy determines how many chunks a MuxWriter gathers before writing
+// them to its destination at once, trading latency for fewer system calls.
+type FlushPolicy byte
+
+const (
+	// FlushPerChunk writes every chunk as soon as it has been encoded.
+	FlushPerChunk FlushPolicy = iota
+	// FlushPerMessage gathers chunks until a message has been completely
+	// encoded, and then writes them together. This is the default.
+	FlushPerMessage
+	// FlushCoalesced gathers chunks until there are no more messages
+	// waiting to be sent, writing the chunks of as many messages as
+	// possible together.
+	FlushCoalesced
+)
+
+// maxBatchSize is the number of bytes after which a MuxWriter writes the chunks
+// that it has gathered, regardless of its FlushPolicy, so that a large message
+// can still be overtaken by messages of a higher priority.
+const maxBatchSize = 64 * 1024
+
+// MuxWriter is an implementation of the Writer interface which interleaves the
+// chunks of messages being written concurrently, instead of writing each
+// message as a whole. Each time a chunk is written, it belongs to the message
+// with the highest Priority (see PriorityOf) among those waiting, such that a
+// large video message does not hold back audio and control messages for the
+// entire time it takes to send it. Messages of the same Priority are sent in
+// the order that they were written.
+//
+// Messages written over the same chunk stream are always sent in the order that
+// they were written, and never interleaved with one another, since type 3
+// headers continue the message in progress over their chunk stream. Messages
+// that should be interleaved must therefore be written over different chunk
+// streams.
+//
+// There is no goroutine behind a MuxWriter: every call to Write (and Resize)
+// takes turns writing the next chunks of whichever messages are due, and
+// returns once its own message has been written completely. Only one of them
+// writes to the destination at a time, so a MuxWriter is safe to share between
+// goroutines. Chunks are gathered according to the FlushPolicy, and written
+// with a single (vectored, see net.Buffers) write. Headers are compressed the
+// same way as they are by the DefaultWriter.
+type MuxWriter struct {
+	// w is the DefaultWriter used to compress headers, and to write and
+	// count bytes.
+	w *DefaultWriter
+
+	// mu guards all fields below, and is the Locker of cond.
+	mu sync.Mutex
+	// cond is broadcast to every time a batch of chunks has been written.
+	cond *sync.Cond
+	// flush is the FlushPolicy used to decide when to write the chunks
+	// gathered thus far.
+	flush FlushPolicy
+	// streams maps chunk stream IDs to the messages waiting to be sent over
+	// them, in the order that they were written.
+	streams map[uint32][]*muxMessage
+	// seq is the sequence number given to the next message written.
+	seq uint64
+	// busy is true while a batch of chunks is being written to the
+	// destination.
+	busy bool
+	// err is the first error encountered while writing to the destination.
+	// Once it is set, all pending and future writes fail with it.
+	err error
+}
+
+// muxMessage is a single message waiting to be sent by a MuxWriter.
+type muxMessage struct {
+	// chunk is the message being sent.
+	chunk *Chunk
+	// priority is the Priority of the message.
+	priority Priority
+	// seq is the order in which the message was written.
+	seq uint64
+	// resize is the write size to switch to once the message has been
+	// sent, or zero if the write size should be left alone.
+	resize int
+
+	// header is the compressed Header of the first chunk, set once the
+	// first chunk is sent.
+	header *Header
+	// sent is the number of payload bytes that have been sent.
+	sent int
+
+	// done is true once the message has been sent completely, or could
+	// not be sent.
+	done bool
+	// err is the error encountered while sending the message, if any.
+	err error
+}
+
+var _ Writer = new(MuxWriter)
+
+// NewMuxWriter returns a new *MuxWriter writing to the given io.Writer, and
+// splitting messages according to the given write size.
+func NewMuxWriter(dest io.Writer, writeSize int) *MuxWriter {
+	m := &MuxWriter{
+		w: &DefaultWriter{
+			dest:      dest,
+			writeSize: writeSize,
+		},
+		flush:   FlushPerMessage,
+		streams: make(map[uint32][]*muxMessage),
+	}
+	m.cond = sync.NewCond(&m.mu)
+
+	return m
+}
+
+// Write implements the Write function defined in the Writer interface. It
+// blocks until every chunk of the given message has been written, or an error
+// was encountered.
+func (m *MuxWriter) Write(c *Chunk) error {
+	return m.send(&muxMessage{chunk: c, priority: PriorityOf(c)})
+}
+
+// Resize writes the given chunk, and then changes the write size to `size`
+// before any other chunk (including those of messages already in progress) is
+// written. See DefaultWriter.Resize.
+func (m *MuxWriter) Resize(c *Chunk, size int) error {
+	return m.send(&muxMessage{
+		chunk:    c,
+		priority: PriorityOf(c),
+		resize:   size,
+	})
+}
+
+// WriteSize implements the WriteSize function defined in the Writer interface.
+func (m *MuxWriter) WriteSize() int { return m.w.WriteSize() }
+
+// SetWriteSize implements the SetWriteSize function defined in the Writer
+// interface. The new size applies to the next chunk written, even if it
+// belongs to a message already in progress.
+func (m *MuxWriter) SetWriteSize(size int) { m.w.SetWriteSize(size) }
+
+// SetFlushPolicy changes the FlushPolicy of this MuxWriter.
+func (m *MuxWriter) SetFlushPolicy(flush FlushPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.flush = flush
+}
+
+// BytesWritten returns the total number of bytes written by this MuxWriter,
+// modulo 2^32. See DefaultWriter.BytesWritten.
+func (m *MuxWriter) BytesWritten() uint32 { return m.w.BytesWritten() }
+
+// send queues the given message, and takes turns writing chunks until it has
+// been sent completely.
+func (m *MuxWriter) send(msg *muxMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+
+	msg.seq = m.seq
+	m.seq++
+
+	id := msg.chunk.Header.BasicHeader.StreamId
+	m.streams[id] = append(m.streams[id], msg)
+
+	for !msg.done {
+		if m.busy {
+			m.cond.Wait()
+			continue
+		}
+
+		m.writeBatch()
+	}
+
+	return msg.err
+}
+
+// writeBatch gathers the next chunks to be sent, according to the FlushPolicy,
+// and writes them to the destination. It must be called with mu held, and
+// releases it while writing.
+func (m *MuxWriter) writeBatch() {
+	var (
+		batch net.Buffers
+		size  int
+		sent  []*muxMessage
+	)
+
+	for size < maxBatchSize {
+		next := m.next()
+		if next == nil {
+			break
+		}
+
+		out := new(bytes.Buffer)
+		if err := m.encode(next, out); err != nil {
+			next.err = err
+			m.finish(next)
+			sent = append(sent, next)
+
+			break
+		}
+
+		batch = append(batch, out.Bytes())
+		size += out.Len()
+
+		if next.sent >= len(next.chunk.Data) {
+			if next.resize > 0 {
+				m.w.SetWriteSize(next.resize)
+			}
+
+			m.finish(next)
+			sent = append(sent, next)
+
+			if m.flush == FlushPerMessage {
+				break
+			}
+		}
+
+		if m.flush == FlushPerChunk {
+			break
+		}
+	}
+
+	var err error
+	if len(batch) > 0 {
+		m.busy = true
+		m.mu.Unlock()
+
+		err = m.w.writeBuffers(batch)
+
+		m.mu.Lock()
+		m.busy = false
+	}
+
+	for _, msg := range sent {
+		msg.done = true
+		if msg.err == nil {
+			msg.err = err
+		}
+	}
+
+	if err != nil {
+		m.fail(err)
+	}
+
+	m.cond.Broadcast()
+}
+
+// next returns the message that the next chunk should be written for: the
+// first message of each chunk stream is considered, and the one with the
+// highest priority, that was written first, is returned. It must be called
+// with mu held.
+func (m *MuxWriter) next() *muxMessage {
+	var next *muxMessage
+	for _, msgs := range m.streams {
+		msg := msgs[0]
+		if next == nil ||
+			msg.priority < next.priority ||
+			(msg.priority == next.priority && msg.seq < next.seq) {
+
+			next = msg
+		}
+	}
+
+	return next
+}
+
+// encode writes the next chunk of the given message, including its header, to
+// the given buffer, and advances the message past it. It must be called with
+// mu held.
+func (m *MuxWriter) encode(msg *muxMessage, out *bytes.Buffer) error {
+	header := msg.header
+	if header == nil {
+		msg.header = m.w.Compress(msg.chunk.Header)
+		header = msg.header
+	} else {
+		header = continuation(msg.header)
+	}
+
+	if err := header.Write(out); err != nil {
+		return err
+	}
+
+	n := spec.Min(len(msg.chunk.Data)-msg.sent, m.w.WriteSize())
+	out.Write(msg.chunk.Data[msg.sent : msg.sent+n])
+	msg.sent += n
+
+	return nil
+}
+
+// finish removes the given message from its chunk stream's queue, once its
+// last chunk has been gathered. It must be called with mu held.
+func (m *MuxWriter) finish(msg *muxMessage) {
+	id := msg.chunk.Header.BasicHeader.StreamId
+	if msgs := m.streams[id][1:]; len(msgs) > 0 {
+		m.streams[id] = msgs
+	} else {
+		delete(m.streams, id)
+	}
+}
+
+// fail records the given error, and fails every message still waiting to be
+// sent with it. It must be called with mu held.
+func (m *MuxWriter) fail(err error) {
+	m.err = err
+
+	for id, msgs := range m.streams {
+		for _, msg := range msgs {
+			msg.done = true
+			msg.err = err
+		}
+
+		delete(m.streams, id)
+	}
+}
@@ -0,0 +1,252 @@
+package chunk_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/rtmp/chunk"
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedWriter is an io.Writer that pushes each write over a channel, blocking
+// until it has been received.
+type gatedWriter struct {
+	writes chan []byte
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{writes: make(chan []byte)}
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	g.writes <- append([]byte{}, p...)
+	return len(p), nil
+}
+
+func newMuxChunk(streamId uint32, typeId byte, length int) *chunk.Chunk {
+	return &chunk.Chunk{
+		Header: &chunk.Header{
+			BasicHeader: chunk.BasicHeader{0, streamId},
+			MessageHeader: chunk.MessageHeader{
+				0, 0, false, uint32(length), typeId, 1,
+			},
+		},
+		Data: bytes.Repeat([]byte{typeId}, length),
+	}
+}
+
+func TestPriorityOfOrdersMessagesByType(t *testing.T) {
+	for typeId, priority := range map[byte]chunk.Priority{
+		0x01: chunk.PriorityControl,
+		0x05: chunk.PriorityControl,
+		0x06: chunk.PriorityControl,
+		0x08: chunk.PriorityAudio,
+		0x09: chunk.PriorityVideo,
+		0x12: chunk.PriorityCommand,
+		0x14: chunk.PriorityCommand,
+	} {
+		assert.Equal(t, priority, chunk.PriorityOf(newMuxChunk(3, typeId, 0)))
+	}
+}
+
+func TestMuxWriterWritesLikeTheDefaultWriter(t *testing.T) {
+	expected, actual := new(bytes.Buffer), new(bytes.Buffer)
+
+	w := chunk.NewWriter(expected, 4)
+	m := chunk.NewMuxWriter(actual, 4)
+
+	for _, c := range []*chunk.Chunk{
+		newMuxChunk(6, 9, 10), newMuxChunk(6, 9, 10), newMuxChunk(4, 8, 0),
+	} {
+		w.Write(c)
+		assert.Nil(t, m.Write(c))
+	}
+
+	assert.Equal(t, expected.Bytes(), actual.Bytes())
+	assert.Equal(t, uint32(actual.Len()), m.BytesWritten())
+}
+
+func TestMuxWriterInterleavesHigherPriorityMessages(t *testing.T) {
+	g := newGatedWriter()
+	m := chunk.NewMuxWriter(g, 4)
+	m.SetFlushPolicy(chunk.FlushPerChunk)
+
+	go m.Write(newMuxChunk(6, 9, 16))
+	first := <-g.writes
+
+	go m.Write(newMuxChunk(4, 8, 4))
+	time.Sleep(10 * time.Millisecond)
+
+	var ids []byte
+	for _, w := range append([][]byte{first}, <-g.writes, <-g.writes,
+		<-g.writes, <-g.writes) {
+
+		ids = append(ids, w[0]&0x3f)
+	}
+
+	// The audio message overtakes the rest of the video message as soon
+	// as the video chunk already in flight has been written.
+	assert.Equal(t, []byte{6, 6, 4, 6, 6}, ids)
+}
+
+func TestMuxWriterKeepsMessagesOnTheSameStreamInOrder(t *testing.T) {
+	g := newGatedWriter()
+	m := chunk.NewMuxWriter(g, 4)
+	m.SetFlushPolicy(chunk.FlushPerChunk)
+
+	go m.Write(newMuxChunk(6, 9, 8))
+	first := <-g.writes
+
+	go m.Write(newMuxChunk(6, 8, 4))
+	time.Sleep(10 * time.Millisecond)
+
+	second, third := <-g.writes, <-g.writes
+
+	assert.Equal(t, byte(0x09), first[len(first)-1])
+	assert.Equal(t, byte(0x09), second[len(second)-1])
+	assert.Equal(t, byte(0x08), third[len(third)-1])
+}
+
+func TestMuxWriterOutputCanBeRead(t *testing.T) {
+	g := newGatedWriter()
+	m := chunk.NewMuxWriter(g, chunk.DefaultReadSize)
+	m.SetFlushPolicy(chunk.FlushPerChunk)
+
+	video := newMuxChunk(6, 9, 300)
+	audio := newMuxChunk(4, 8, 200)
+
+	go m.Write(video)
+	buf := bytes.NewBuffer(<-g.writes)
+
+	go m.Write(audio)
+	time.Sleep(10 * time.Millisecond)
+
+	for i := 0; i < 4; i++ {
+		buf.Write(<-g.writes)
+	}
+
+	r := chunk.NewReader(buf, chunk.DefaultReadSize, chunk.NewNormalizer())
+	go r.Recv()
+
+	a, v := <-r.Chunks(), <-r.Chunks()
+
+	assert.Equal(t, audio.Data, a.Data)
+	assert.Equal(t, video.Data, v.Data)
+}
+
+func TestMuxWriterResizesBeforeContinuingOtherMessages(t *testing.T) {
+	g := newGatedWriter()
+	m := chunk.NewMuxWriter(g, 4)
+	m.SetFlushPolicy(chunk.FlushPerChunk)
+
+	go m.Write(newMuxChunk(6, 9, 16))
+	<-g.writes
+
+	go m.Resize(newMuxChunk(2, 1, 4), 8)
+	time.Sleep(10 * time.Millisecond)
+
+	<-g.writes // the video chunk already in flight
+	<-g.writes // the control message
+	rest := <-g.writes
+
+	assert.Equal(t, 8, m.WriteSize())
+	// A type 3 header, and the remaining 8 bytes of the video message.
+	assert.Len(t, rest, 1+8)
+}
+
+// countingWriter is an io.Writer that counts the number of writes made to it.
+type countingWriter struct {
+	mu     sync.Mutex
+	writes int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writes++
+	return len(p), nil
+}
+
+func TestMuxWriterFlushesOncePerMessage(t *testing.T) {
+	c := new(countingWriter)
+	m := chunk.NewMuxWriter(c, 4)
+
+	m.Write(newMuxChunk(6, 9, 16))
+	m.Write(newMuxChunk(6, 9, 16))
+
+	assert.Equal(t, 2, c.writes)
+}
+
+func TestMuxWriterFlushesOncePerChunk(t *testing.T) {
+	c := new(countingWriter)
+	m := chunk.NewMuxWriter(c, 4)
+	m.SetFlushPolicy(chunk.FlushPerChunk)
+
+	m.Write(newMuxChunk(6, 9, 16))
+
+	assert.Equal(t, 4, c.writes)
+}
+
+func TestMuxWriterCoalescesWaitingMessages(t *testing.T) {
+	g := newGatedWriter()
+	m := chunk.NewMuxWriter(g, 4)
+	m.SetFlushPolicy(chunk.FlushCoalesced)
+
+	// The video message is written first, and holds up the others until
+	// it has been received.
+	go m.Write(newMuxChunk(6, 9, 16))
+	time.Sleep(10 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := uint32(0); i < 3; i++ {
+		wg.Add(1)
+		go func(id uint32) {
+			defer wg.Done()
+			m.Write(newMuxChunk(id, 8, 4))
+		}(10 + i)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	<-g.writes
+	all := <-g.writes
+	wg.Wait()
+
+	assert.Len(t, all, 3*(12+4))
+}
+
+func benchmarkMuxWriter(b *testing.B, flush chunk.FlushPolicy) {
+	c := new(countingWriter)
+	m := chunk.NewMuxWriter(c, 4096)
+	m.SetFlushPolicy(flush)
+
+	audio := newMuxChunk(4, 8, 256)
+	video := newMuxChunk(6, 9, 64*1024)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if i%4 == 0 {
+				m.Write(video)
+			} else {
+				m.Write(audio)
+			}
+		}
+	})
+
+	b.ReportMetric(float64(c.writes)/float64(b.N), "writes/msg")
+}
+
+func BenchmarkMuxWriterFlushPerChunk(b *testing.B) {
+	benchmarkMuxWriter(b, chunk.FlushPerChunk)
+}
+
+func BenchmarkMuxWriterFlushPerMessage(b *testing.B) {
+	benchmarkMuxWriter(b, chunk.FlushPerMessage)
+}
+
+func BenchmarkMuxWriterFlushCoalesced(b *testing.B) {
+	benchmarkMuxWriter(b, chunk.FlushCoalesced)
+}
@@ -14,11 +14,20 @@ type Normalizer interface {
 	// the missing information filled in.
 	//
 	// For Type 1 and 2 basic headers, this means filling in the stream ID
-	// and length from the last chunk that was received on any chunk stream.
+	// (and, for Type 2, the length and type ID) from the last chunk that
+	// was received over the matching chunk stream ID.
 	// For Type 3 headers, this means replacing the "missing" message
 	// header, with the last full message header sent over the matching
 	// chunk stream ID.
 	//
+	// The absolute timestamp of the message is also computed, and stored
+	// in the Timestamp field of the Header, by accumulating the timestamp
+	// deltas received over the same chunk stream.
+	//
+	// Normalize must be called exactly once per message, with the header
+	// of the first chunk of that message. Headers of continuation chunks
+	// must not be normalized, or the timestamp would be advanced twice.
+	//
 	// Calling Normalize also updates the last received chunk to the one
 	// that was just normalized, eliminating the need to call the
 	// "Set<chunk|last>" methods.
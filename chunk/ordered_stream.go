@@ -0,0 +1,29 @@
+package chunk
+
+// OrderedStream is an implementation of the Stream interface which carries the
+// chunks of multiple RTMP chunk streams at once. Unlike a MultiStream, it is
+// fed directly by the Parser, so chunks are delivered strictly in the order
+// that they were read off the wire, regardless of which chunk stream they were
+// sent over (see Chunk.Seq).
+type OrderedStream struct {
+	// IDs are the RTMP chunk stream IDs carried by this OrderedStream.
+	IDs []uint32
+	// in is the internal channel used to propogate chunks out.
+	in chan *Chunk
+}
+
+var _ Stream = new(OrderedStream)
+
+// NewOrderedStream returns a new instance of the *OrderedStream type, carrying
+// the chunk streams with the given IDs. It initializes all internal channels.
+func NewOrderedStream(ids ...uint32) *OrderedStream {
+	return &OrderedStream{
+		IDs: ids,
+		in:  make(chan *Chunk),
+	}
+}
+
+// In implements the chunk.Stream.In function.
+func (o *OrderedStream) In() <-chan *Chunk { return o.in }
+
+func (o *OrderedStream) feed() chan *Chunk { return o.in }
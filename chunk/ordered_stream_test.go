@@ -0,0 +1,25 @@
+package chunk_test
+
+import (
+	"testing"
+
+	"github.com/WatchBeam/rtmp/chunk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrderedStreamCarriesTheGivenChunkStreams(t *testing.T) {
+	o := chunk.NewOrderedStream(3, 4, 5)
+
+	assert.Equal(t, []uint32{3, 4, 5}, o.IDs)
+	assert.NotNil(t, o.In())
+}
+
+func TestParserReturnsTheOrderedStreamCarryingAChunkStream(t *testing.T) {
+	p := chunk.NewParser(nil)
+
+	multi, _ := p.Stream(3, 4)
+	single, err := p.Stream(4)
+
+	assert.Nil(t, err)
+	assert.Equal(t, multi, single)
+}
@@ -34,13 +34,22 @@ type Parser struct {
 	// reader is the Reader that chunks are read from.
 	reader Reader
 
-	// smu guards streams
+	// smu guards streams, closed, seq and started
 	smu sync.Mutex
 	// wg waits for the Recv loop to complete itself.
 	wg sync.WaitGroup
+	// started is true once Recv has been called, or once the Parser has
+	// been closed without it, in which case Recv returns immediately.
+	started bool
 	// streams maps chunk stream IDs (contained in the basic header of all
-	// chunks) to their appropriate chunk Stream
-	streams map[uint32]*stream
+	// chunks) to their appropriate chunk Stream. Several IDs may map to the
+	// same *OrderedStream.
+	streams map[uint32]feeder
+	// closed is true once all chunk streams have been closed. Chunk streams
+	// asked for afterwards are closed immediately.
+	closed bool
+	// seq is the sequence number given to the next chunk read.
+	seq uint64
 
 	// errs holds a channel of all errors encountered during the read/write
 	// process.
@@ -48,6 +57,13 @@ type Parser struct {
 	// closer holds a channel that closes the Stream when anything is
 	// written to it.
 	closer chan struct{}
+	// done is closed once Recv has returned.
+	done chan struct{}
+
+	// emu guards err
+	emu sync.Mutex
+	// err is the fatal error that the Reader encountered, if any.
+	err error
 }
 
 // NewParser allocates and returns a pointer to a new instance of the Parser
@@ -58,9 +74,10 @@ type Parser struct {
 func NewParser(reader Reader) *Parser {
 	return &Parser{
 		reader:  reader,
-		streams: make(map[uint32]*stream),
+		streams: make(map[uint32]feeder),
 		errs:    make(chan error),
 		closer:  make(chan struct{}),
+		done:    make(chan struct{}),
 	}
 }
 
@@ -68,12 +85,13 @@ func NewParser(reader Reader) *Parser {
 // arguments. This works in either one of two cases:
 //
 //  1) a single chunk stream (1 argument) is asked for, and either the one that
-//  already exists, or a new instance of one is returned.
+//  already exists (which may be an OrderedStream carrying it), or a new
+//  instance of one is returned.
 //
-//  2) multiple chunk streams are asked for, and a MultiStream is returned
-//  containing all of those chunk streams. If a single stream has already been
-//  asked for in the set of streams to concatenate, an error is returned, and no
-//  new chunk streams are created.
+//  2) multiple chunk streams are asked for, and an OrderedStream is returned
+//  containing all of those chunk streams, in the order that their chunks are
+//  read. If a single stream has already been asked for in the set of streams
+//  to concatenate, an error is returned, and no new chunk streams are created.
 func (p *Parser) Stream(ids ...uint32) (Stream, error) {
 	if len(ids) == 0 {
 		return nil, errors.New(
@@ -87,7 +105,7 @@ func (p *Parser) Stream(ids ...uint32) (Stream, error) {
 		id := ids[0]
 
 		if _, ok := p.streams[id]; !ok {
-			p.streams[id] = NewStream(id)
+			p.streams[id] = p.newStream(id)
 		}
 
 		return p.streams[id], nil
@@ -100,64 +118,215 @@ func (p *Parser) Stream(ids ...uint32) (Stream, error) {
 		}
 	}
 
-	multi := NewMultiStream()
+	ordered := NewOrderedStream(ids...)
+	p.add(ordered)
 
 	for _, id := range ids {
-		stream := NewStream(id)
-		p.streams[id] = stream
-
-		multi.Append(stream)
+		p.streams[id] = ordered
 	}
 
-	return multi, nil
+	return ordered, nil
+}
+
+// newStream returns a new chunk stream with the given ID. See add.
+func (p *Parser) newStream(id uint32) *stream {
+	s := NewStream(id)
+	p.add(s)
+
+	return s
+}
+
+// add closes the given chunk stream right away if all chunk streams have
+// already been closed. It must be called with smu held.
+func (p *Parser) add(s feeder) {
+	if p.closed {
+		close(s.feed())
+	}
 }
 
 // Errs returns a channel of errors which contains all reading errors
-// encountered as a result of dealing with _any_ chunk stream.
+// encountered as a result of dealing with _any_ chunk stream, which reading was
+// able to recover from. It is closed once Recv returns.
 func (p *Parser) Errs() <-chan error { return p.errs }
 
+// Done returns a channel which is closed once Recv has returned, either because
+// the Parser was closed, or because the Reader encountered a fatal error. By
+// then, all chunk streams and the Errs() channel have been closed.
+func (p *Parser) Done() <-chan struct{} { return p.done }
+
+// Err returns the fatal error which caused the Reader to stop (see Reader.Err),
+// or nil if it has not stopped, or the Parser was closed.
+func (p *Parser) Err() error {
+	p.emu.Lock()
+	defer p.emu.Unlock()
+
+	return p.err
+}
+
+// Acks returns a channel of sequence numbers that are due to be acknowledged to
+// the peer (see Reader.Acks).
+func (p *Parser) Acks() <-chan uint32 { return p.reader.Acks() }
+
 // Close halts the read/normalize process from all chunk streams and closes each
-// "child" input channel of all `Stream`s.
-func (p *Parser) Close() { p.closer <- struct{}{}; p.wg.Wait() }
+// "child" input channel of all `Stream`s. It is safe to call once Recv has
+// already returned, or if it was never called, in which case the Reader is left
+// alone and Recv returns immediately if called later on.
+func (p *Parser) Close() {
+	p.smu.Lock()
+	started := p.started
+	p.started = true
+	p.smu.Unlock()
+
+	if !started {
+		p.release(nil)
+		return
+	}
+
+	select {
+	case p.closer <- struct{}{}:
+	case <-p.done:
+	}
+
+	p.wg.Wait()
+}
 
 // Recv is responsible for processing the chunks coming off of the underlying
 // chunk.Reader. It first normalizes them and then places them onto the
 // appropriate chunk stream, ensuring first that it exists. If an error is
-// encountered, it is returned. If a close{} operation is sent, then the
-// function will clean up after itself, and subsequently return.
+// encountered, it is returned. If a close{} operation is sent, or the Reader
+// stops because of a fatal error, then the function will clean up after
+// itself, and subsequently return.
+//
+// Chunks are read off of the Reader whether or not their chunk stream is being
+// read: each chunk stream has a backlog of the chunks that its consumer has yet
+// to take, which is delivered from its own goroutine. A consumer which stops
+// reading (such as one blocked on the peer's window) thus does not keep the
+// chunks of the others, nor the flow control messages which would unblock it,
+// from being read. Chunks that have been read before the Reader stops are
+// delivered before the chunk streams are closed.
 //
 // Recv runs within its own goroutine.
 func (p *Parser) Recv() {
+	p.smu.Lock()
+	if p.started {
+		p.smu.Unlock()
+		return
+	}
+	p.started = true
 	p.wg.Add(1)
+	p.smu.Unlock()
+
 	defer p.wg.Done()
 
 	go p.reader.Recv()
 
+	// backlogs maps the chunk streams which have been read for to their
+	// backlog, whose goroutines are tracked by delivering, and return once
+	// stop is closed.
+	backlogs := make(map[feeder]*backlog)
+	var delivering sync.WaitGroup
+	stop := make(chan struct{})
+
+	halt := func() {
+		close(stop)
+		delivering.Wait()
+	}
+
 	for {
 		select {
 		case in := <-p.reader.Chunks():
-			s, err := p.Stream(in.StreamId())
-			if err != nil {
-				p.errs <- err
-				continue
+			s := p.streamOf(in)
+
+			b, ok := backlogs[s]
+			if !ok {
+				b = newBacklog()
+				backlogs[s] = b
+
+				delivering.Add(1)
+				go func() {
+					defer delivering.Done()
+					b.deliver(s.feed(), stop)
+				}()
 			}
 
-			s.(*stream).in <- in
+			b.push(in)
 		case err := <-p.reader.Errs():
-			p.errs <- err
-		case <-p.closer:
-			p.reader.Close()
+			select {
+			case p.errs <- err:
+			case <-p.closer:
+				halt()
+				p.shutdown(nil)
+				return
+			}
+		case <-p.reader.Done():
+			for _, b := range backlogs {
+				b.finish()
+			}
 
-			close(p.errs)
-			close(p.closer)
+			delivered := make(chan struct{})
+			go func() {
+				delivering.Wait()
+				close(delivered)
+			}()
 
-			p.smu.Lock()
-			for _, stream := range p.streams {
-				close(stream.in)
+			select {
+			case <-delivered:
+			case <-p.closer:
+				halt()
 			}
-			p.smu.Unlock()
 
+			p.shutdown(p.reader.Err())
+			return
+		case <-p.closer:
+			halt()
+			p.shutdown(nil)
 			return
 		}
 	}
 }
+
+// streamOf numbers the given chunk, and returns the chunk stream that it should
+// be sent down, creating it if necessary.
+func (p *Parser) streamOf(c *Chunk) feeder {
+	p.smu.Lock()
+	defer p.smu.Unlock()
+
+	c.Seq = p.seq
+	p.seq++
+
+	s, ok := p.streams[c.StreamId()]
+	if !ok {
+		s = p.newStream(c.StreamId())
+		p.streams[c.StreamId()] = s
+	}
+
+	return s
+}
+
+// shutdown closes the Reader, and releases the Parser. See release.
+func (p *Parser) shutdown(err error) {
+	p.reader.Close()
+	p.release(err)
+}
+
+// release records the given fatal error (if any), and closes all chunk streams,
+// as well as the errs and done channels.
+func (p *Parser) release(err error) {
+	p.emu.Lock()
+	p.err = err
+	p.emu.Unlock()
+
+	p.smu.Lock()
+	p.closed = true
+	closed := make(map[feeder]bool)
+	for _, s := range p.streams {
+		if !closed[s] {
+			close(s.feed())
+			closed[s] = true
+		}
+	}
+	p.smu.Unlock()
+
+	close(p.errs)
+	close(p.done)
+}
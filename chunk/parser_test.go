@@ -10,7 +10,9 @@ package chunk_test
 // this bug becomes fixed.
 
 import (
+	"bytes"
 	"errors"
+	"io"
 	"reflect"
 	"testing"
 
@@ -32,6 +34,7 @@ func TestStreamPropogatesErrors(t *testing.T) {
 	reader.On("Recv").Return().Once()
 	reader.On("Chunks").Return(chunks)
 	reader.On("Errs").Return(errs)
+	reader.On("Done").Return(make(chan struct{}))
 	reader.On("Close").Return()
 
 	p := chunk.NewParser(reader)
@@ -55,6 +58,7 @@ func TestStreamClosesAfterSignalSent(t *testing.T) {
 	reader.On("Recv").Return().Once()
 	reader.On("Chunks").Return(chunks)
 	reader.On("Errs").Return(errs)
+	reader.On("Done").Return(make(chan struct{}))
 	reader.On("Close").Return().Once()
 
 	p := chunk.NewParser(reader)
@@ -104,3 +108,134 @@ func TestParserDoesNotReturnMultiStreamsWhenStreamsAlreadyExist(t *testing.T) {
 	assert.Nil(t, multiStream)
 	assert.Equal(t, "rtmp/chunk: stream 1 already exists", err.Error())
 }
+
+func TestParserShutsDownWhenTheReaderFails(t *testing.T) {
+	p := chunk.NewParser(chunk.NewReader(
+		new(bytes.Buffer), chunk.DefaultReadSize, chunk.NoopNormalizer,
+	))
+	single, _ := p.Stream(2)
+	multi, _ := p.Stream(3, 4)
+
+	go p.Recv()
+	<-p.Done()
+
+	_, open := <-single.In()
+	assert.False(t, open)
+	_, open = <-multi.In()
+	assert.False(t, open)
+	_, open = <-p.Errs()
+	assert.False(t, open)
+
+	assert.Equal(t, io.EOF, p.Err())
+	p.Close()
+}
+
+func TestParserClosesStreamsAskedForAfterShuttingDown(t *testing.T) {
+	p := chunk.NewParser(chunk.NewReader(
+		new(bytes.Buffer), chunk.DefaultReadSize, chunk.NoopNormalizer,
+	))
+
+	go p.Recv()
+	<-p.Done()
+
+	s, _ := p.Stream(5)
+	_, open := <-s.In()
+
+	assert.False(t, open)
+}
+
+func TestParserCloseLeavesNoError(t *testing.T) {
+	chunks := make(chan *chunk.Chunk)
+
+	reader := &MockReader{}
+	reader.On("Recv").Return().Once()
+	reader.On("Chunks").Return(chunks)
+	reader.On("Errs").Return(make(chan error))
+	reader.On("Done").Return(make(chan struct{}))
+	reader.On("Close").Return()
+
+	p := chunk.NewParser(reader)
+	s, _ := p.Stream(2)
+
+	go p.Recv()
+	p.Close()
+
+	_, open := <-s.In()
+
+	assert.False(t, open)
+	assert.Nil(t, p.Err())
+}
+
+func TestParserCloseReleasesAParserThatNeverReceived(t *testing.T) {
+	p := chunk.NewParser(&MockReader{})
+	s, _ := p.Stream(2)
+
+	p.Close()
+
+	_, open := <-s.In()
+	assert.False(t, open)
+	_, open = <-p.Errs()
+	assert.False(t, open)
+	<-p.Done()
+
+	p.Recv()
+}
+
+func TestParserDeliversMultipleChunkStreamsInOrder(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := chunk.NewWriter(buf, chunk.DefaultReadSize)
+	for _, id := range []uint32{8, 3, 2, 4, 3, 8} {
+		w.Write(newMuxChunk(id, 0x14, 4))
+	}
+
+	p := chunk.NewParser(chunk.NewReader(
+		buf, chunk.DefaultReadSize, chunk.NewNormalizer(),
+	))
+	control, _ := p.Stream(2)
+	multi, _ := p.Stream(3, 4, 5, 8)
+
+	go p.Recv()
+	defer p.Close()
+
+	var ids []uint32
+	var seqs []uint64
+	for i := 0; i < 5; i++ {
+		c := <-multi.In()
+
+		ids = append(ids, c.StreamId())
+		seqs = append(seqs, c.Seq)
+
+		if i == 1 {
+			assert.Equal(t, uint64(2), (<-control.In()).Seq)
+		}
+	}
+
+	assert.Equal(t, []uint32{8, 3, 4, 3, 8}, ids)
+	assert.Equal(t, []uint64{0, 1, 3, 4, 5}, seqs)
+}
+
+func TestParserKeepsReadingPastChunkStreamsThatAreNotRead(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := chunk.NewWriter(buf, chunk.DefaultReadSize)
+	for i := 0; i < 10; i++ {
+		w.Write(newMuxChunk(3, 0x14, 4))
+	}
+	w.Write(newMuxChunk(2, 0x03, 4))
+
+	p := chunk.NewParser(chunk.NewReader(
+		buf, chunk.DefaultReadSize, chunk.NewNormalizer(),
+	))
+	control, _ := p.Stream(2)
+	blocked, _ := p.Stream(3)
+
+	go p.Recv()
+	defer p.Close()
+
+	// None of the chunks on stream 3 are read until the Acknowledgement
+	// has been delivered.
+	assert.Equal(t, byte(0x03), (<-control.In()).TypeId())
+	for i := 0; i < 10; i++ {
+		c := <-blocked.In()
+		assert.Equal(t, uint64(i), c.Seq)
+	}
+}
@@ -0,0 +1,65 @@
+package chunk
+
+import (
+	"math/bits"
+	"sync"
+)
+
+const (
+	// minPoolShift is the base-2 logarithm of the smallest pooled buffer.
+	minPoolShift = 7
+	// maxPoolShift is the base-2 logarithm of the largest pooled buffer.
+	// Payloads larger than that are allocated, and left to the garbage
+	// collector.
+	maxPoolShift = 24
+)
+
+// pools holds a pool of payload buffers for every power of two between 1 <<
+// minPoolShift and 1 << maxPoolShift. Buffers are stored by pointer, so that
+// putting them back does not allocate.
+var pools [maxPoolShift - minPoolShift + 1]sync.Pool
+
+// poolClass returns the index into pools of the smallest buffers that are able
+// to hold `n` bytes, or -1 if there are none.
+func poolClass(n int) int {
+	shift := bits.Len(uint(n - 1))
+	if shift > maxPoolShift {
+		return -1
+	}
+
+	if shift < minPoolShift {
+		shift = minPoolShift
+	}
+
+	return shift - minPoolShift
+}
+
+// getBuffer returns a buffer of length `n`, taken from the pools if possible.
+func getBuffer(n int) *[]byte {
+	class := poolClass(n)
+	if class < 0 {
+		buf := make([]byte, n)
+		return &buf
+	}
+
+	if buf, ok := pools[class].Get().(*[]byte); ok {
+		*buf = (*buf)[:n]
+		return buf
+	}
+
+	buf := make([]byte, n, 1<<uint(class+minPoolShift))
+	return &buf
+}
+
+// putBuffer hands the given buffer back to the pools, if it was taken from
+// them.
+func putBuffer(buf *[]byte) {
+	size := cap(*buf)
+
+	class := poolClass(size)
+	if class < 0 || size != 1<<uint(class+minPoolShift) {
+		return
+	}
+
+	pools[class].Put(buf)
+}
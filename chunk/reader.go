@@ -14,6 +14,18 @@ type Reader interface {
 	// If a chunk has been completely read, it is built and pushed over the
 	// channel.
 	//
+	// Protocol control messages that affect the chunk layer are acted upon
+	// by the Reader: Set Chunk Size (type 1) changes the ReadSize, and is
+	// not pushed over the channel; Abort Message (type 2) discards the
+	// partially read message on the named chunk stream, and Window
+	// Acknowledgement Size (type 5) sets the size of the window after which
+	// acknowledgements are pushed over the Acks() channel. Both are pushed
+	// over the channel as usual.
+	//
+	// Implementations may bound the resources used on behalf of the peer
+	// (see Limits), in which case they stop reading once the peer exceeds
+	// them.
+	//
 	// Recv runs within its own goroutine.
 	Recv()
 
@@ -29,10 +41,26 @@ type Reader interface {
 	// This channel is not buffered.
 	Chunks() <-chan *Chunk
 	// Errs provides a read-only channel of errors that occurred during the
-	// parsing procesr.
+	// parsing procesr, and that reading was able to recover from. Errors
+	// that it was not are returned by Err instead.
 	Errs() <-chan error
-	// Close causes the Recv goroutine to return.
+	// Acks provides a read-only channel of sequence numbers that are due to
+	// be acknowledged to the peer, according to the window size set by the
+	// last Window Acknowledgement Size message that it sent. If the reader
+	// of this channel falls behind, only the latest sequence number is
+	// kept.
+	Acks() <-chan uint32
+	// Close causes the Recv goroutine to return. It is safe to call once
+	// Recv has already returned.
 	Close()
+	// Done returns a channel which is closed once the Recv goroutine has
+	// returned, either because the Reader was closed, or because of a fatal
+	// error.
+	Done() <-chan struct{}
+	// Err returns the fatal error which caused the Recv goroutine to
+	// return, or nil if it has not returned, or returned because the Reader
+	// was closed.
+	Err() error
 }
 
 // NewReader allocates and returns a pointer to a new instance of the Reader
@@ -40,13 +68,20 @@ type Reader interface {
 // uses the provided `src`, `readSize`, and `normalizer` as initialization
 // variables.
 func NewReader(src io.Reader, readSize int, normalizer Normalizer) Reader {
+	window := new(AckWindow)
+
 	return &DefaultReader{
-		src:        src,
+		src:        &countingReader{src: src, window: window},
+		window:     window,
+		acks:       make(chan uint32, 1),
 		readSize:   readSize,
+		limits:     DefaultLimits,
 		normalizer: normalizer,
 		builders:   make(map[uint32]*Builder),
+		extended:   make(map[uint32]bool),
 		chunks:     make(chan *Chunk),
 		errs:       make(chan error),
 		closer:     make(chan struct{}),
+		done:       make(chan struct{}),
 	}
 }
@@ -33,6 +33,21 @@ func (r *MockReader) Errs() <-chan error {
 	return args.Get(0).(chan error)
 }
 
+func (r *MockReader) Acks() <-chan uint32 {
+	args := r.Called()
+	return args.Get(0).(chan uint32)
+}
+
 func (r *MockReader) Close() {
 	r.Called()
 }
+
+func (r *MockReader) Done() <-chan struct{} {
+	args := r.Called()
+	return args.Get(0).(chan struct{})
+}
+
+func (r *MockReader) Err() error {
+	args := r.Called()
+	return args.Error(0)
+}
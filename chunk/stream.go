@@ -8,6 +8,14 @@ type Stream interface {
 	In() <-chan *Chunk
 }
 
+// feeder is implemented by the Streams that a Parser sends chunks down.
+type feeder interface {
+	Stream
+
+	// feed returns the channel that chunks are sent down.
+	feed() chan *Chunk
+}
+
 // stream is a simple implementation of the Stream interface that corresponds to
 // an RTMP chunk stream.
 type stream struct {
@@ -29,3 +37,5 @@ func NewStream(id uint32) *stream {
 }
 
 func (s *stream) In() <-chan *Chunk { return s.in }
+
+func (s *stream) feed() chan *Chunk { return s.in }
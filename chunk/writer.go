@@ -1,8 +1,10 @@
 package chunk
 
 import (
+	"bytes"
 	"io"
 	"io/ioutil"
+	"net"
 )
 
 var (
@@ -27,10 +29,37 @@ type Writer interface {
 	// WriteSize returns the maximum length of a chunk's payload before that
 	// chunk must be split into multiple chunks.
 	WriteSize() int
-	// SetWriteSize changes the write size of this particular Writer.
+	// SetWriteSize changes the write size of this particular Writer. The
+	// peer is not told about the change; see control.Stream.SetChunkSize
+	// for that. Sizes below one byte are ignored, since no chunk could
+	// ever be written.
 	SetWriteSize(writeSize int)
 }
 
+// BuffersWriter is implemented by io.Writers which are able to write several
+// buffers with a single (vectored) write, such as connections wrapping a
+// *net.TCPConn.
+type BuffersWriter interface {
+	// WriteBuffers writes the given buffers, returning the number of bytes
+	// written, and any error encountered along the way.
+	WriteBuffers(bufs net.Buffers) (int64, error)
+}
+
+// WriteBuffers writes the given buffers to w with a single write. If w is a
+// BuffersWriter, or a network connection that supports vectored writes, the
+// buffers are written as they are; otherwise they are first joined together.
+func WriteBuffers(w io.Writer, bufs net.Buffers) (int64, error) {
+	switch dest := w.(type) {
+	case BuffersWriter:
+		return dest.WriteBuffers(bufs)
+	case *net.TCPConn, *net.UnixConn:
+		return bufs.WriteTo(dest)
+	default:
+		n, err := w.Write(bytes.Join(bufs, nil))
+		return int64(n), err
+	}
+}
+
 // NewWriter returns a default implementation of the Writer interface.
 func NewWriter(dest io.Writer, writeSize int) Writer {
 	return &DefaultWriter{
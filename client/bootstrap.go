@@ -0,0 +1,214 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/WatchBeam/rtmp/chunk"
+	"github.com/WatchBeam/rtmp/cmd/conn"
+	"github.com/WatchBeam/rtmp/control"
+)
+
+var (
+	// DefaultBootstrap is the Bootstrap sent by most RTMP servers, which
+	// OBS, ffmpeg and FMLE all expect.
+	DefaultBootstrap = &Bootstrap{
+		WindowAckSize: 2500000,
+		PeerBandwidth: 2500000,
+		LimitType:     control.LimitTypeDynamic,
+		ChunkSize:     DefaultChunkSize,
+		StreamBegin:   true,
+		BWDone:        true,
+	}
+
+	// connectName is the AMF0 encoding of the name of the connect command,
+	// which every connect command begins with.
+	connectName = []byte{
+		0x02, 0x00, 0x07, 'c', 'o', 'n', 'n', 'e', 'c', 't',
+	}
+	// resultName is the AMF0 encoding of the name of the _result command,
+	// which every successful response begins with.
+	resultName = []byte{
+		0x02, 0x00, 0x07, '_', 'r', 'e', 's', 'u', 'l', 't',
+	}
+)
+
+const (
+	// connectTransactionId is the transaction ID of the connect command,
+	// which its response carries, too.
+	connectTransactionId float64 = 1
+)
+
+// Bootstrap is the sequence of messages sent to a client once it has sent its
+// connect command, before the connect command is handed to the application.
+// The messages are sent in the order of the fields below, and any of them may
+// be left out by leaving its field zero.
+//
+// The response to the connect command remains up to the application. The
+// onBWDone command is the exception to the above: it is sent only once the
+// application has written its successful (_result) response, since Flash
+// clients ignore it until they have connected.
+type Bootstrap struct {
+	// WindowAckSize is the size of the acknowledgement window announced to
+	// the client with a Window Acknowledgement Size message.
+	WindowAckSize uint32
+	// PeerBandwidth and LimitType are the output bandwidth limit imposed
+	// on the client with a Set Peer Bandwidth message.
+	PeerBandwidth uint32
+	LimitType     control.LimitType
+	// ChunkSize is the chunk size changed to, and announced with a Set
+	// Chunk Size message (see Client.SetChunkSize). The message is left
+	// out if the chunk size is already in effect, as DefaultChunkSize is
+	// once the Client has handshaked.
+	ChunkSize uint32
+	// StreamBegin is true if a Stream Begin event is sent for the
+	// NetConnection's message stream (ID 0).
+	StreamBegin bool
+	// BWDone is true if an onBWDone command is sent after the response to
+	// the connect command.
+	BWDone bool
+}
+
+// SetBootstrap sets the Bootstrap that is sent to the client once it has sent
+// its connect command, which is nil (sending nothing) by default. It returns
+// ErrStarted if the Client has already been started, either by Handshake, or
+// by ReadMessage or WriteMessage.
+func (c *Client) SetBootstrap(b *Bootstrap) error {
+	c.smu.Lock()
+	defer c.smu.Unlock()
+
+	if c.state != stateNew {
+		return c.stateErr()
+	}
+
+	c.bootstrap = b
+
+	return nil
+}
+
+// bootstrapOn sends the Bootstrap if the given message is the first connect
+// command received from the client. It must be called by one goroutine at a
+// time.
+func (c *Client) bootstrapOn(msg *chunk.Chunk) error {
+	if c.bootstrap == nil || c.booted || !isConnect(msg) {
+		return nil
+	}
+	c.booted = true
+
+	b := c.bootstrap
+
+	var ctrls []control.Control
+	if b.WindowAckSize > 0 {
+		ctrls = append(ctrls, &control.WindowAckSize{b.WindowAckSize})
+	}
+	if b.PeerBandwidth > 0 {
+		ctrls = append(ctrls, &control.SetPeerBandwidth{
+			b.PeerBandwidth, b.LimitType,
+		})
+	}
+	if b.ChunkSize > 0 && int(b.ChunkSize) != c.writer.WriteSize() {
+		ctrls = append(ctrls, control.NewSetChunkSize(b.ChunkSize))
+	}
+	if b.StreamBegin {
+		ctrls = append(ctrls, &control.StreamBegin{0})
+	}
+
+	for _, ctrl := range ctrls {
+		if err := c.writeControl(ctrl); err != nil {
+			return err
+		}
+	}
+
+	c.emu.Lock()
+	c.bwDue = b.BWDone
+	c.emu.Unlock()
+
+	return nil
+}
+
+// bwDoneAfter writes the onBWDone command of the Bootstrap to the given
+// chunk.Writer, if it is due, and the given message (which has just been
+// written) is the successful response to the connect command.
+func (c *Client) bwDoneAfter(w chunk.Writer, msg *chunk.Chunk) error {
+	if !isConnectResult(msg) {
+		return nil
+	}
+
+	c.emu.Lock()
+	due := c.bwDue
+	c.bwDue = false
+	c.emu.Unlock()
+
+	if !due {
+		return nil
+	}
+
+	done, err := conn.NewChunker(conn.ChunkStreamId).Chunk(new(conn.OnBWDone))
+	if err != nil {
+		return err
+	}
+
+	return w.Write(done)
+}
+
+// isConnect returns true if the given message is an AMF0 connect command.
+func isConnect(msg *chunk.Chunk) bool {
+	return isCommand(msg, connectName)
+}
+
+// isConnectResult returns true if the given message is an AMF0 _result command
+// answering the connect command, as told by its transaction ID.
+func isConnectResult(msg *chunk.Chunk) bool {
+	if !isCommand(msg, resultName) {
+		return false
+	}
+
+	// The name is followed by the transaction ID, an AMF0 number (marker
+	// 0x00), encoded as a big-endian float64.
+	txid := msg.Data[len(resultName):]
+	if len(txid) < 9 || txid[0] != 0x00 {
+		return false
+	}
+
+	id := math.Float64frombits(binary.BigEndian.Uint64(txid[1:9]))
+
+	return id == connectTransactionId
+}
+
+// isCommand returns true if the given message is an AMF0 command with the
+// given encoded name.
+func isCommand(msg *chunk.Chunk, name []byte) bool {
+	return msg.TypeId() == 0x14 && bytes.HasPrefix(msg.Data, name)
+}
+
+// bwDoneWriter is the chunk.Writer that the Net() writes to, which sends the
+// onBWDone command of the Bootstrap after the response to the connect command.
+type bwDoneWriter struct {
+	chunk.Writer
+
+	c *Client
+}
+
+// Write implements the chunk.Writer.Write function.
+func (w *bwDoneWriter) Write(msg *chunk.Chunk) error {
+	if err := w.Writer.Write(msg); err != nil {
+		return err
+	}
+
+	return w.c.bwDoneAfter(w.Writer, msg)
+}
+
+// hook notes the first command, and sends the Bootstrap from the Dispatch loop
+// of the Net(), tearing down the Client if it could not be sent.
+func (c *Client) hook(msg *chunk.Chunk) {
+	c.commandOn(msg)
+
+	if err := c.bootstrapOn(msg); err != nil {
+		c.emu.Lock()
+		c.err = err
+		c.emu.Unlock()
+
+		c.cancel()
+	}
+}
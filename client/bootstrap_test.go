@@ -0,0 +1,206 @@
+package client_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/WatchBeam/rtmp/chunk"
+	"github.com/WatchBeam/rtmp/client"
+	"github.com/WatchBeam/rtmp/cmd/conn"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	// ConnectChunk holds the beginning of an AMF0 connect command.
+	ConnectChunk = &chunk.Chunk{
+		Header: &chunk.Header{
+			BasicHeader: chunk.BasicHeader{0, 3},
+			MessageHeader: chunk.MessageHeader{
+				0, 0, false, 10, 0x14, 0,
+			},
+		},
+		Data: []byte{0x02, 0x00, 0x07, 'c', 'o', 'n', 'n', 'e', 'c', 't'},
+	}
+
+	// ResultChunk holds the beginning of an AMF0 _result command answering
+	// the connect command (transaction ID 1).
+	ResultChunk = &chunk.Chunk{
+		Header: &chunk.Header{
+			BasicHeader: chunk.BasicHeader{0, 3},
+			MessageHeader: chunk.MessageHeader{
+				0, 0, false, 19, 0x14, 0,
+			},
+		},
+		Data: []byte{
+			0x02, 0x00, 0x07, '_', 'r', 'e', 's', 'u', 'l', 't',
+			0x00, 0x3f, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		},
+	}
+
+	// OtherResultChunk holds the beginning of an AMF0 _result command
+	// answering another command (transaction ID 2).
+	OtherResultChunk = &chunk.Chunk{
+		Header: &chunk.Header{
+			BasicHeader: chunk.BasicHeader{0, 3},
+			MessageHeader: chunk.MessageHeader{
+				0, 0, false, 19, 0x14, 0,
+			},
+		},
+		Data: []byte{
+			0x02, 0x00, 0x07, '_', 'r', 'e', 's', 'u', 'l', 't',
+			0x00, 0x40, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		},
+	}
+)
+
+// peerReader reads the messages sent to the peer, recording the type ID of
+// every chunk read, including those of the messages swallowed by the reader.
+type peerReader struct {
+	*chunk.DefaultReader
+
+	headers *headerRecorder
+}
+
+// headerRecorder is a chunk.Normalizer which records the type ID of every
+// header normalized.
+type headerRecorder struct {
+	chunk.Normalizer
+
+	types []byte
+}
+
+// Normalize implements the chunk.Normalizer.Normalize function.
+func (h *headerRecorder) Normalize(header *chunk.Header) *chunk.Header {
+	header = h.Normalizer.Normalize(header)
+	h.types = append(h.types, header.MessageHeader.TypeId)
+
+	return header
+}
+
+// count returns the number of chunks read with the given type ID.
+func (h *headerRecorder) count(typeId byte) int {
+	var n int
+	for _, t := range h.types {
+		if t == typeId {
+			n++
+		}
+	}
+
+	return n
+}
+
+// assertBootstrapped reads the control messages of the DefaultBootstrap off of
+// the given reader, asserting that they are received in order, and that the
+// chunk size announced while handshaking is not announced again.
+func assertBootstrapped(t *testing.T, r *peerReader) {
+	var types []byte
+	for i := 0; i < 3; i++ {
+		c, err := r.ReadMessage()
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		types = append(types, c.TypeId())
+	}
+
+	assert.Equal(t, []byte{0x05, 0x06, 0x04}, types)
+	assert.Equal(t, int(client.DefaultChunkSize), r.ReadSize())
+	assert.Equal(t, 1, r.headers.count(0x01))
+}
+
+// newPeerReader returns a reader of the messages sent to the given peer.
+func newPeerReader(peer net.Conn) *peerReader {
+	headers := &headerRecorder{Normalizer: chunk.NewNormalizer()}
+
+	return &peerReader{
+		DefaultReader: chunk.NewReader(
+			peer, chunk.DefaultReadSize, headers,
+		).(*chunk.DefaultReader),
+		headers: headers,
+	}
+}
+
+func TestReadingConnectSendsTheBootstrap(t *testing.T) {
+	server, peer := dial(t)
+	defer peer.Close()
+
+	c := client.New(server)
+	defer c.Close()
+
+	assert.Nil(t, c.SetBootstrap(client.DefaultBootstrap))
+
+	go func() {
+		handshakeAsPeer(t, peer)
+		chunk.NewWriter(peer, chunk.DefaultReadSize).Write(ConnectChunk)
+	}()
+
+	received, err := c.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, ConnectChunk.Data, received.Data)
+
+	assertBootstrapped(t, newPeerReader(peer))
+}
+
+func TestOnBWDoneFollowsTheResponseToConnect(t *testing.T) {
+	server, peer := dial(t)
+	defer peer.Close()
+
+	c := client.New(server)
+	defer c.Close()
+
+	assert.Nil(t, c.SetBootstrap(client.DefaultBootstrap))
+
+	go func() {
+		handshakeAsPeer(t, peer)
+		chunk.NewWriter(peer, chunk.DefaultReadSize).Write(ConnectChunk)
+	}()
+
+	_, err := c.ReadMessage()
+	assert.Nil(t, err)
+
+	r := newPeerReader(peer)
+	assertBootstrapped(t, r)
+
+	assert.Nil(t, c.WriteMessage(OtherResultChunk))
+	assert.Nil(t, c.WriteMessage(ResultChunk))
+	assert.Nil(t, c.WriteMessage(ResultChunk))
+
+	done, _ := conn.NewChunker(conn.ChunkStreamId).Chunk(new(conn.OnBWDone))
+	for _, expected := range []*chunk.Chunk{
+		OtherResultChunk, ResultChunk, done, ResultChunk,
+	} {
+		c, err := r.ReadMessage()
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		assert.Equal(t, expected.TypeId(), c.TypeId())
+		assert.Equal(t, string(expected.Data), string(c.Data))
+	}
+}
+
+func TestReceivingConnectSendsTheBootstrap(t *testing.T) {
+	server, peer := dial(t)
+	defer peer.Close()
+
+	c := client.New(server)
+	defer c.Close()
+
+	assert.Nil(t, c.SetBootstrap(client.DefaultBootstrap))
+
+	go handshakeAsPeer(t, peer)
+	assert.Nil(t, c.Handshake())
+
+	chunk.NewWriter(peer, chunk.DefaultReadSize).Write(ConnectChunk)
+
+	assertBootstrapped(t, newPeerReader(peer))
+}
+
+func TestSetBootstrapFailsOnceStarted(t *testing.T) {
+	c, peer := startedClient(t, context.Background())
+	defer peer.Close()
+	defer c.Close()
+
+	assert.Equal(t, client.ErrStarted, c.SetBootstrap(client.DefaultBootstrap))
+}
@@ -1,7 +1,12 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"io"
+	"net"
+	"sync"
+	"time"
 
 	"github.com/WatchBeam/rtmp/chunk"
 	"github.com/WatchBeam/rtmp/cmd"
@@ -9,72 +14,528 @@ import (
 	"github.com/WatchBeam/rtmp/handshake"
 )
 
+const (
+	// DefaultChunkSize is the chunk size announced to every client once the
+	// handshake has completed.
+	DefaultChunkSize uint32 = 4096
+)
+
+var (
+	// ErrStarted is returned when using the channel API (see Handshake) of a
+	// Client whose ReadMessage and WriteMessage functions have been used, or
+	// vice versa.
+	ErrStarted = errors.New("rtmp/client: client already started")
+)
+
+// state is the stage of its lifecycle that a Client is in.
+type state byte
+
+const (
+	// stateNew is the state of a Client that has not yet handshaken.
+	stateNew state = iota
+	// stateMessages is the state of a Client that has handshaken, and is
+	// used through ReadMessage and WriteMessage.
+	stateMessages
+	// stateChannels is the state of a Client that has been started by
+	// Handshake.
+	stateChannels
+	// stateClosed is the state of a Client that was closed before it
+	// handshook.
+	stateClosed
+)
+
 // Client represents a client connected to a RTMP server (see
 // github.com/WatchBeam/rtmp/server for more). Clients are able to be written to
 // and read from, and may have additional metadata attached to them in the
 // future.
+//
+// A Client may be used in either one of two ways: through channels, by calling
+// Handshake and then consuming the Controls(), Net() and Errs(), or
+// synchronously, in the style of a net.Conn, by calling ReadMessage and
+// WriteMessage. The latter starts no more than a single goroutine, and does
+// not allocate any of the channels used by the former.
 type Client struct {
+	// transport is the connection that chunks are read from and written
+	// to, once it has been handshaken.
+	transport *transport
+	// reader reads chunks off of the connection, either through chunks, or
+	// by ReadMessage.
+	reader *chunk.DefaultReader
+	// writer writes chunks to the connection.
+	writer *chunk.MuxWriter
+
+	// once guards the construction of the fields below, which only the
+	// channel API uses (see setup).
+	once   sync.Once
 	chunks *chunk.Parser
 
 	controlStream *control.Stream
 	cmdManager    *cmd.Manager
 
+	// flow is the FlowWriter that all non-control messages are written
+	// through, honoring the bandwidth limits set by the client.
+	flow *control.FlowWriter
+
+	// ctx is the context which tears down the Client once it is done.
+	ctx context.Context
+	// cancel cancels ctx, and is called by Close.
+	cancel context.CancelFunc
+
+	// smu guards state
+	smu sync.Mutex
+	// state is the stage of its lifecycle that the Client is in.
+	state state
+	// done is closed once the Client has been torn down.
+	done chan struct{}
+
+	// bootstrap is the Bootstrap sent once the client has connected, if
+	// any.
+	bootstrap *Bootstrap
+	// booted is true once the client has connected. It is only accessed
+	// by the goroutine reading messages from the client.
+	booted bool
+
+	// timeouts are the Timeouts in effect.
+	timeouts Timeouts
+
+	// emu guards err, awaiting, info and bwDue
+	emu sync.Mutex
+	// err is the error which caused the Client to be torn down from within,
+	// such as when the Bootstrap could not be sent.
+	err error
+	// awaiting is true while the read deadline for the first command is
+	// set.
+	awaiting bool
+	// info is what was learned about the client while handshaking.
+	info handshake.HandshakeInfo
+	// bwDue is true once the Bootstrap has been sent, until its onBWDone
+	// command has been sent.
+	bwDue bool
+
 	// Conn represents the readable and writeable connection that links to
 	// the client. This may be a net.Conn, or even just a bytes.Buffer.
 	Conn io.ReadWriter
 }
 
 // New instantiates and returns a pointer to a new instance of type Client. The
-// client is initialized with the given connection.
+// client is initialized with the given connection, and is torn down only once
+// it is closed, or the connection is lost. See NewContext.
 func New(conn io.ReadWriter) *Client {
-	chunkWriter := chunk.NewWriter(conn, 4096)
-	chunks := chunk.NewParser(chunk.NewReader(
-		conn, chunk.DefaultReadSize, chunk.NewNormalizer(),
-	))
+	return NewContext(context.Background(), conn)
+}
 
-	controlChunks, _ := chunks.Stream(2)
-	netChunks, _ := chunks.Stream(3, 4, 5, 8)
+// NewContext instantiates and returns a pointer to a new instance of type
+// Client, initialized with the given connection. Once the given context is
+// done, the Client is torn down, as if Close had been called.
+func NewContext(ctx context.Context, conn io.ReadWriter) *Client {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &transport{conn}
 
 	return &Client{
-		chunks: chunks,
+		transport: t,
+		reader: chunk.NewReader(
+			t, chunk.DefaultReadSize, chunk.NewNormalizer(),
+		).(*chunk.DefaultReader),
+		writer: chunk.NewMuxWriter(t, chunk.DefaultReadSize),
 
-		controlStream: control.NewStream(
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		timeouts: DefaultTimeouts,
+
+		Conn: conn,
+	}
+}
+
+// setup constructs the chunk.Parser, control.Stream, control.FlowWriter and
+// cmd.Manager used by the channel API, the first time that it is called.
+func (c *Client) setup() {
+	c.once.Do(func() {
+		c.chunks = chunk.NewParser(c.reader)
+
+		controlChunks, _ := c.chunks.Stream(2)
+		netChunks, _ := c.chunks.Stream(3, 4, 5, 8)
+
+		c.controlStream = control.NewStream(
 			controlChunks,
-			chunkWriter,
+			c.writer,
 			control.NewParser(),
 			control.NewChunker(),
-		),
+		)
+		c.controlStream.SetAcks(c.chunks.Acks())
 
-		cmdManager: cmd.New(netChunks, chunkWriter),
+		c.flow = control.NewFlowWriter(c.writer, control.FlowBlock)
+		c.reader.SetWindowUpdater(c.flow)
 
-		Conn: conn,
-	}
+		c.cmdManager = cmd.New(netChunks, &bwDoneWriter{c.flow, c})
+		c.cmdManager.SetHook(c.hook)
+	})
 }
 
 // Handshake preforms the handshake operation against the connecting client. If
 // an error is encountered during any point of the handshake process, it will be
 // returned immediately.
 //
-// If no error is encounterd while handshaking, the chunk reading process will
-// begin.
+// If no error is encounterd while handshaking, DefaultChunkSize is announced
+// to the client (see SetChunkSize), and the chunk reading process will begin,
+// along with the Recv loop of the Controls(), and the Dispatch loop of the
+// Net() (which manages its children). None of them should be started
+// elsewhere.
+//
+// If the Client's context is done while handshaking, the connection is closed
+// (if it is an io.Closer) in order to interrupt it. Either way, Close should be
+// called if Handshake returns an error.
+//
+// Handshake returns ErrStarted if the Client has already been started, either
+// by Handshake, or by ReadMessage or WriteMessage.
 //
 // See github.com/WatchBeam/RTMP/handshake for details.
 func (c *Client) Handshake() error {
-	if err := handshake.With(&handshake.Param{
-		Conn: c.Conn,
-	}).Handshake(); err != nil {
+	c.smu.Lock()
+	defer c.smu.Unlock()
+
+	if c.state != stateNew {
+		return c.stateErr()
+	}
+
+	if err := c.handshake(); err != nil {
 		return err
 	}
 
+	c.setup()
+	c.state = stateChannels
+
 	go c.chunks.Recv()
+	go c.controlStream.Recv()
+	go c.cmdManager.Dispatch(true)
+	go c.teardown()
+
+	return nil
+}
+
+// ReadMessage reads the next message sent by the client, blocking until it has
+// been completely read. Set Chunk Size messages are acted upon and swallowed,
+// and Acknowledgements are sent to the client as they become due, but all
+// other messages (including the remaining protocol control messages) are
+// returned as they are. Any error returned is fatal, and the Client should be
+// closed.
+//
+// The handshake is performed the first time that ReadMessage or WriteMessage
+// is called, after which ReadMessage may be called from one goroutine at a
+// time. If the Client has been started by Handshake, ErrStarted is returned
+// instead.
+func (c *Client) ReadMessage() (*chunk.Chunk, error) {
+	if err := c.messages(); err != nil {
+		return nil, err
+	}
+
+	msg, err := c.reader.ReadMessage()
+	if err != nil {
+		return nil, c.commandErr(err)
+	}
+	c.commandOn(msg)
+
+	if err := c.bootstrapOn(msg); err != nil {
+		return nil, err
+	}
+
+	select {
+	case seq := <-c.reader.Acks():
+		err := c.writeControl(&control.Acknowledgement{seq})
+		if err != nil {
+			return nil, err
+		}
+	default:
+	}
+
+	return msg, nil
+}
+
+// WriteMessage writes the given message to the client, blocking until it has
+// been completely written. The message is split according to the chunk size
+// (see SetChunkSize), and its header is compressed as it is by the
+// chunk.DefaultWriter. Unlike messages written through the channel API, it is
+// not subject to the bandwidth limit set by the client. If the message is the
+// response to the connect command, it is followed by the onBWDone command of
+// the Bootstrap, if any.
+//
+// The handshake is performed the first time that ReadMessage or WriteMessage
+// is called, after which WriteMessage is safe to call from multiple goroutines.
+// If the Client has been started by Handshake, ErrStarted is returned instead.
+func (c *Client) WriteMessage(msg *chunk.Chunk) error {
+	if err := c.messages(); err != nil {
+		return err
+	}
+
+	if err := c.writer.Write(msg); err != nil {
+		return err
+	}
+
+	return c.bwDoneAfter(c.writer, msg)
+}
+
+// messages performs the handshake if the Client has not yet handshaken, and
+// returns an error if it may not be used through ReadMessage and WriteMessage.
+func (c *Client) messages() error {
+	c.smu.Lock()
+	defer c.smu.Unlock()
+
+	switch c.state {
+	case stateMessages:
+		return c.ctx.Err()
+	case stateNew:
+	default:
+		return c.stateErr()
+	}
+
+	if err := c.handshake(); err != nil {
+		return err
+	}
+
+	c.state = stateMessages
+	go c.await()
+
+	return nil
+}
+
+// stateErr returns the error explaining why a Client that is not in stateNew
+// may not be started: either it has been closed, or it has already been
+// started. It must be called with smu held.
+func (c *Client) stateErr() error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+
+	return ErrStarted
+}
+
+// handshake performs the handshake within the Handshake timeout, announces the
+// DefaultChunkSize, and starts waiting for the first command. If the Client's
+// context is done while handshaking, the connection is closed, and the
+// context's error is returned. Once the handshake has completed, chunks are
+// read from and written to the connection returned by the Handshaker, which is
+// encrypted if the client asked for it (RTMPE).
+func (c *Client) handshake() error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-c.ctx.Done():
+			c.closeConn()
+		case <-stop:
+		}
+	}()
+
+	h := handshake.With(&handshake.Param{
+		Conn:    c.Conn,
+		Timeout: c.timeouts.Handshake,
+	})
+
+	err := h.Handshake()
+
+	c.emu.Lock()
+	c.info = h.Info()
+	c.emu.Unlock()
+
+	if err != nil {
+		if ctxErr := c.ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		return err
+	}
+	c.transport.ReadWriter = h.Conn()
+
+	if err := c.SetChunkSize(DefaultChunkSize); err != nil {
+		return err
+	}
+
+	c.awaitCommand()
 
 	return nil
 }
 
+// Close tears down the Client, and blocks until every goroutine that it started
+// has returned. It may be called any number of times.
+func (c *Client) Close() {
+	c.cancel()
+
+	c.smu.Lock()
+	if c.state == stateNew {
+		c.state = stateClosed
+		c.closeConn()
+		close(c.done)
+	}
+	c.smu.Unlock()
+
+	<-c.done
+}
+
+// await closes the connection once the Client's context is done, interrupting
+// any call to ReadMessage or WriteMessage in progress. It is the only goroutine
+// started for a Client used through those.
+func (c *Client) await() {
+	defer close(c.done)
+
+	<-c.ctx.Done()
+	c.closeConn()
+}
+
+// teardown waits for the Client's context to be done, for reading from the
+// client to stop, or for the Controls() to stop (such as when the client missed
+// too many pings), and then stops every goroutine started by Handshake: first
+// the FlowWriter and the connection, so that no write remains blocked (either
+// on the client's window, or on a client that stopped reading), then the
+// consumers of incoming chunks, and finally the chunk reading process.
+func (c *Client) teardown() {
+	defer close(c.done)
+
+	select {
+	case <-c.ctx.Done():
+	case <-c.chunks.Done():
+	case <-c.controlStream.Done():
+	}
+	c.cancel()
+
+	c.flow.Close()
+	c.closeConn()
+
+	c.cmdManager.Close()
+	<-c.cmdManager.Done()
+
+	c.controlStream.Close()
+	<-c.controlStream.Done()
+
+	c.chunks.Close()
+}
+
+// transport is an io.ReadWriter whose underlying connection is replaced once
+// the handshake has completed, before any chunk is read from or written to it.
+type transport struct {
+	io.ReadWriter
+}
+
+var _ chunk.BuffersWriter = new(transport)
+
+// WriteBuffers implements the chunk.BuffersWriter.WriteBuffers function, such
+// that chunks are written to the underlying connection with vectored writes
+// whenever it supports them. Encrypted connections do not, as every buffer has
+// to be encrypted first.
+func (t *transport) WriteBuffers(bufs net.Buffers) (int64, error) {
+	return chunk.WriteBuffers(t.ReadWriter, bufs)
+}
+
+// closeConn closes the connection, if it is an io.Closer.
+func (c *Client) closeConn() {
+	if closer, ok := c.Conn.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// SetChunkSize changes the size of the chunks written to the client, telling
+// the client about the new size beforehand. A size of zero is rejected with
+// control.ErrInvalidChunkSize. See control.Stream.SetChunkSize.
+func (c *Client) SetChunkSize(size uint32) error {
+	return c.writeControl(control.NewSetChunkSize(size))
+}
+
+// writeControl writes the given control sequence to the client. Once a Set
+// Chunk Size control sequence has been written, the write size is changed to
+// match it, before any other chunk is written.
+func (c *Client) writeControl(ctrl control.Control) error {
+	chunk, err := control.NewChunker().Chunk(ctrl)
+	if err != nil {
+		return err
+	}
+
+	if size, ok := ctrl.(*control.SetChunkSize); ok {
+		return c.writer.Resize(chunk, int(size.ChunkSize()))
+	}
+
+	return c.writer.Write(chunk)
+}
+
+// Errs returns the errors encountered while reading chunks from the client,
+// which reading was able to recover from. See Err for those that it was not.
+func (c *Client) Errs() <-chan error {
+	c.setup()
+	return c.chunks.Errs()
+}
+
+// Done returns a channel which is closed once the Client has been torn down,
+// either because it was closed (or its context was done), or because no more
+// chunks could be read from the client, such as when the connection has been
+// lost, or the client exceeded one of the chunk.Limits.
+func (c *Client) Done() <-chan struct{} { return c.done }
+
+// Err returns the error which caused the Bootstrap not to be sent, or the error
+// which caused the Controls() to stop, such as control.ErrKeepaliveTimeout, or
+// else the error which caused reading from the client to stop, if any, which
+// is ErrFirstCommandTimeout if the client did not send a command in time. The
+// connection should be closed once it is set. Errors returned by ReadMessage
+// are not recorded here.
+func (c *Client) Err() error {
+	c.emu.Lock()
+	err := c.err
+	c.emu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	c.smu.Lock()
+	started := c.state == stateChannels
+	c.smu.Unlock()
+
+	if started {
+		if err := c.controlStream.Err(); err != nil {
+			return err
+		}
+	}
+
+	return c.commandErr(c.reader.Err())
+}
+
+// HandshakeInfo returns what was learned about the client while handshaking
+// with it, such as the version of its implementation, and the handshake scheme
+// that it used. If the handshake failed, it holds whatever was learned until
+// then.
+func (c *Client) HandshakeInfo() handshake.HandshakeInfo {
+	c.emu.Lock()
+	defer c.emu.Unlock()
+
+	return c.info
+}
+
+// RTT returns the smoothed round-trip time to the client, as measured by the
+// keepalive pings sent by the Controls(), which must be enabled with
+// control.Stream.SetKeepalive before calling Handshake. It returns zero until
+// it has been measured.
+func (c *Client) RTT() time.Duration { return c.Controls().RTT() }
+
 // Controls returns the stream of control sequences that are being received
 // from the connected client.
-func (c *Client) Controls() *control.Stream { return c.controlStream }
+func (c *Client) Controls() *control.Stream {
+	c.setup()
+	return c.controlStream
+}
+
+// Flow returns the *control.FlowWriter that all messages sent over the
+// NetConnection, NetStream and DataStream pass through. It may be used to
+// change the FlowPolicy applied to this client.
+func (c *Client) Flow() *control.FlowWriter {
+	c.setup()
+	return c.flow
+}
 
 // Net returns the *cmd.Manager responsible for handling the NetConnection,
 // NetStrema, and DataStream exchanged with this client.
-func (c *Client) Net() *cmd.Manager { return c.cmdManager }
+func (c *Client) Net() *cmd.Manager {
+	c.setup()
+	return c.cmdManager
+}
@@ -2,10 +2,22 @@ package client_test
 
 import (
 	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"runtime"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/WatchBeam/rtmp/chunk"
 	"github.com/WatchBeam/rtmp/client"
+	"github.com/WatchBeam/rtmp/cmd/conn"
+	"github.com/WatchBeam/rtmp/control"
+	"github.com/WatchBeam/rtmp/handshake"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewConstructsNewClients(t *testing.T) {
@@ -15,3 +27,437 @@ func TestNewConstructsNewClients(t *testing.T) {
 	assert.IsType(t, &client.Client{}, c)
 	assert.Equal(t, b, c.Conn)
 }
+
+// dial returns both ends of a TCP connection over the loopback interface.
+func dial(t *testing.T) (server, peer net.Conn) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer l.Close()
+
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, _ := l.Accept()
+		accepted <- conn
+	}()
+
+	peer, err = net.Dial("tcp", l.Addr().String())
+	require.Nil(t, err)
+
+	return <-accepted, peer
+}
+
+// handshakeAsPeer performs the client side of the RTMP handshake.
+func handshakeAsPeer(t *testing.T, peer net.Conn) {
+	c0c1 := make([]byte, 1+1536)
+	c0c1[0] = 0x03
+
+	_, err := peer.Write(c0c1)
+	assert.Nil(t, err)
+
+	s0s1s2 := make([]byte, 1+2*1536)
+	_, err = io.ReadFull(peer, s0s1s2)
+	assert.Nil(t, err)
+
+	_, err = peer.Write(s0s1s2[1 : 1+1536])
+	assert.Nil(t, err)
+}
+
+// startedClient returns a Client which has completed the handshake with its
+// peer.
+func startedClient(t *testing.T, ctx context.Context) (*client.Client, net.Conn) {
+	server, peer := dial(t)
+	c := client.NewContext(ctx, server)
+
+	go handshakeAsPeer(t, peer)
+	require.Nil(t, c.Handshake())
+
+	return c, peer
+}
+
+// settle waits up to a second for the number of goroutines to go down to `max`,
+// and returns the number of goroutines.
+func settle(max int) int {
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > max && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return runtime.NumGoroutine()
+}
+
+// assertNoLeaks fails the test if the number of goroutines does not go back
+// down to `before` within a second.
+func assertNoLeaks(t *testing.T, before int) {
+	if n := settle(before); n > before {
+		buf := make([]byte, 1<<16)
+		t.Fatalf("client: %d goroutines leaked:\n%s", n-before,
+			buf[:runtime.Stack(buf, true)])
+	}
+}
+
+func TestCloseStopsEveryGoroutine(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	c, peer := startedClient(t, context.Background())
+	defer peer.Close()
+
+	c.Close()
+
+	assertNoLeaks(t, before)
+}
+
+func TestCancellingTheContextStopsEveryGoroutine(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, peer := startedClient(t, ctx)
+	defer peer.Close()
+
+	cancel()
+	<-c.Done()
+
+	assertNoLeaks(t, before)
+}
+
+func TestLosingTheConnectionStopsEveryGoroutine(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	c, peer := startedClient(t, context.Background())
+	peer.Close()
+
+	<-c.Done()
+
+	assert.NotNil(t, c.Err())
+	assertNoLeaks(t, before)
+}
+
+func TestCloseReleasesWritesBlockedOnTheWindow(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	c, peer := startedClient(t, context.Background())
+	defer peer.Close()
+	go io.Copy(ioutil.Discard, peer)
+
+	bw, _ := control.NewChunker().Chunk(&control.SetPeerBandwidth{
+		1, control.LimitTypeHard,
+	})
+	chunk.NewWriter(peer, chunk.DefaultReadSize).Write(bw)
+
+	// Media is dropped once the window of a single byte is in effect.
+	c.Flow().SetPolicy(control.FlowDropMedia)
+	for c.Flow().Dropped() == 0 {
+		c.Flow().Write(&chunk.Chunk{
+			Header: &chunk.Header{
+				BasicHeader: chunk.BasicHeader{0, 6},
+				MessageHeader: chunk.MessageHeader{
+					0, 0, false, 4, 0x09, 1,
+				},
+			},
+			Data: []byte{1, 2, 3, 4},
+		})
+	}
+	c.Flow().SetPolicy(control.FlowBlock)
+
+	// The NetConn blocks writing this, and stops listening.
+	c.Net().NetConn().Out() <- new(conn.OnBWDone)
+
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("client: Close blocked on a write")
+	}
+
+	assertNoLeaks(t, before)
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	atomic.AddInt64(&w.n, int64(len(p)))
+	return len(p), nil
+}
+
+func TestLateAcknowledgementsReleaseBlockedWrites(t *testing.T) {
+	c, peer := startedClient(t, context.Background())
+	defer peer.Close()
+	defer c.Close()
+
+	// The handshake is not counted in the window.
+	received := new(countingWriter)
+	go io.Copy(received, peer)
+
+	w := chunk.NewWriter(peer, chunk.DefaultReadSize)
+
+	bw, _ := control.NewChunker().Chunk(&control.SetPeerBandwidth{
+		1, control.LimitTypeHard,
+	})
+	w.Write(bw)
+
+	// Nothing below reads the Controls() or the Net(), and the write
+	// blocks once the window of a single byte is in effect.
+	c.Flow().SetPolicy(control.FlowDropMedia)
+	for c.Flow().Dropped() == 0 {
+		c.Flow().Write(&chunk.Chunk{
+			Header: &chunk.Header{
+				BasicHeader: chunk.BasicHeader{0, 6},
+				MessageHeader: chunk.MessageHeader{
+					0, 0, false, 4, 0x09, 1,
+				},
+			},
+			Data: []byte{1, 2, 3, 4},
+		})
+	}
+	c.Flow().SetPolicy(control.FlowBlock)
+
+	written := make(chan error)
+	go func() {
+		written <- c.Flow().Write(&chunk.Chunk{
+			Header: &chunk.Header{
+				BasicHeader: chunk.BasicHeader{0, 6},
+				MessageHeader: chunk.MessageHeader{
+					0, 0, false, 4, 0x09, 1,
+				},
+			},
+			Data: []byte{1, 2, 3, 4},
+		})
+	}()
+
+	for i := 0; i < 10; i++ {
+		w.Write(ConnectChunk)
+		w.Write(bw)
+	}
+
+	// Give everything written so far time to reach the peer.
+	time.Sleep(10 * time.Millisecond)
+
+	ack, _ := control.NewChunker().Chunk(&control.Acknowledgement{
+		uint32(atomic.LoadInt64(&received.n)),
+	})
+	w.Write(ack)
+
+	select {
+	case err := <-written:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("client: the Acknowledgement did not release the write")
+	}
+}
+
+func TestCloseIsIdempotent(t *testing.T) {
+	c, peer := startedClient(t, context.Background())
+	defer peer.Close()
+
+	c.Close()
+	c.Close()
+}
+
+func TestCloseBeforeHandshaking(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	server, peer := dial(t)
+	defer peer.Close()
+
+	c := client.NewContext(context.Background(), server)
+	c.Close()
+
+	<-c.Done()
+	assert.Equal(t, context.Canceled, c.Handshake())
+	assertNoLeaks(t, before)
+}
+
+func TestReadAndWriteMessages(t *testing.T) {
+	server, peer := dial(t)
+	defer peer.Close()
+
+	c := client.New(server)
+	defer c.Close()
+
+	sent := &chunk.Chunk{
+		Header: &chunk.Header{
+			BasicHeader: chunk.BasicHeader{0, 3},
+			MessageHeader: chunk.MessageHeader{
+				0, 0, false, 4, 0x14, 0,
+			},
+		},
+		Data: []byte{1, 2, 3, 4},
+	}
+
+	go func() {
+		handshakeAsPeer(t, peer)
+		chunk.NewWriter(peer, chunk.DefaultReadSize).Write(sent)
+	}()
+
+	received, err := c.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, sent.Data, received.Data)
+
+	assert.Nil(t, c.WriteMessage(sent))
+
+	r := chunk.NewReader(peer, chunk.DefaultReadSize, chunk.NewNormalizer())
+	echoed, err := r.(*chunk.DefaultReader).ReadMessage()
+
+	assert.Nil(t, err)
+	assert.Equal(t, sent.Data, echoed.Data)
+	assert.Equal(t, int(client.DefaultChunkSize), r.ReadSize())
+}
+
+// vectoredConn is a net.Conn which counts the vectored writes made to it.
+type vectoredConn struct {
+	net.Conn
+
+	writes int32
+}
+
+func (c *vectoredConn) WriteBuffers(bufs net.Buffers) (int64, error) {
+	atomic.AddInt32(&c.writes, 1)
+	return bufs.WriteTo(c.Conn)
+}
+
+func TestMessagesAreWrittenWithVectoredWrites(t *testing.T) {
+	server, peer := dial(t)
+	defer peer.Close()
+
+	conn := &vectoredConn{Conn: server}
+	c := client.New(conn)
+	defer c.Close()
+
+	go handshakeAsPeer(t, peer)
+
+	sent := &chunk.Chunk{
+		Header: &chunk.Header{
+			BasicHeader: chunk.BasicHeader{0, 3},
+			MessageHeader: chunk.MessageHeader{
+				0, 0, false, 4, 0x14, 0,
+			},
+		},
+		Data: []byte{1, 2, 3, 4},
+	}
+	assert.Nil(t, c.WriteMessage(sent))
+
+	r := chunk.NewReader(peer, chunk.DefaultReadSize, chunk.NewNormalizer())
+	received, err := r.(*chunk.DefaultReader).ReadMessage()
+
+	assert.Nil(t, err)
+	assert.Equal(t, sent.Data, received.Data)
+	// Both the Set Chunk Size sent after handshaking, and the message.
+	assert.Equal(t, int32(2), atomic.LoadInt32(&conn.writes))
+}
+
+func TestReadMessageFailsOnceHandshaken(t *testing.T) {
+	c, peer := startedClient(t, context.Background())
+	defer peer.Close()
+	defer c.Close()
+
+	_, err := c.ReadMessage()
+
+	assert.Equal(t, client.ErrStarted, err)
+}
+
+func TestMessagesUseASingleGoroutine(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	server, peer := dial(t)
+	defer peer.Close()
+
+	c := client.New(server)
+
+	handshaken := make(chan struct{})
+	go func() {
+		defer close(handshaken)
+		handshakeAsPeer(t, peer)
+	}()
+
+	assert.Nil(t, c.WriteMessage(&chunk.Chunk{
+		Header: &chunk.Header{
+			BasicHeader:   chunk.BasicHeader{0, 3},
+			MessageHeader: chunk.MessageHeader{0, 0, false, 0, 0x14, 0},
+		},
+	}))
+	<-handshaken
+
+	assert.Equal(t, before+1, settle(before+1))
+	assert.Equal(t, client.ErrStarted, c.Handshake())
+
+	c.Close()
+	assertNoLeaks(t, before)
+}
+
+func TestMissingPingsTearsDownTheClient(t *testing.T) {
+	server, peer := dial(t)
+	defer peer.Close()
+
+	c := client.New(server)
+	c.Controls().SetKeepalive(time.Millisecond, 2)
+
+	go handshakeAsPeer(t, peer)
+	assert.Nil(t, c.Handshake())
+
+	<-c.Done()
+
+	assert.Equal(t, control.ErrKeepaliveTimeout, c.Err())
+}
+
+func TestReadAndWriteEncryptedMessages(t *testing.T) {
+	server, peer := dial(t)
+	defer peer.Close()
+
+	c := client.New(server)
+	defer c.Close()
+
+	sent := &chunk.Chunk{
+		Header: &chunk.Header{
+			BasicHeader: chunk.BasicHeader{0, 3},
+			MessageHeader: chunk.MessageHeader{
+				0, 0, false, 4, 0x14, 0,
+			},
+		},
+		Data: []byte{1, 2, 3, 4},
+	}
+
+	h := handshake.With(&handshake.Param{
+		Conn:      peer,
+		Role:      handshake.RoleClient,
+		Encrypted: true,
+	})
+
+	handshaken := make(chan struct{})
+	go func() {
+		defer close(handshaken)
+
+		assert.Nil(t, h.Handshake())
+		chunk.NewWriter(h.Conn(), chunk.DefaultReadSize).Write(sent)
+	}()
+
+	received, err := c.ReadMessage()
+	require.Nil(t, err)
+	assert.Equal(t, sent.Data, received.Data)
+	<-handshaken
+
+	assert.Nil(t, c.WriteMessage(sent))
+
+	r := chunk.NewReader(h.Conn(), chunk.DefaultReadSize, chunk.NewNormalizer())
+	echoed, err := r.(*chunk.DefaultReader).ReadMessage()
+
+	assert.Nil(t, err)
+	assert.Equal(t, sent.Data, echoed.Data)
+}
+
+func TestClientsRecordTheirHandshake(t *testing.T) {
+	c, peer := startedClient(t, context.Background())
+	defer peer.Close()
+	defer c.Close()
+
+	assert.Equal(t, handshake.HandshakeInfo{
+		Version: 3,
+		Scheme:  handshake.SchemeSimple,
+	}, c.HandshakeInfo())
+}
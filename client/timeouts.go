@@ -0,0 +1,105 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/WatchBeam/rtmp/chunk"
+)
+
+var (
+	// ErrFirstCommandTimeout is returned when the client has not sent a
+	// command within the FirstCommand timeout.
+	ErrFirstCommandTimeout = errors.New("rtmp/client: no command received in time")
+)
+
+// Timeouts bound the time that a client may take at the beginning of a
+// session, so that clients which stall (or trickle bytes in) can not tie up a
+// Client indefinitely. A timeout of zero means that there is no timeout.
+//
+// Timeouts are applied through deadlines on the connection, and only if it has
+// SetDeadline and SetReadDeadline functions, as a net.Conn does.
+type Timeouts struct {
+	// Handshake bounds the time that the handshake may take. If it is
+	// exceeded, the handshake fails with handshake.TimeoutErr.
+	Handshake time.Duration
+	// FirstCommand bounds the time that the client may take to send its
+	// first command (usually connect) once the handshake has completed. If
+	// it is exceeded, reading from the client fails with
+	// ErrFirstCommandTimeout.
+	FirstCommand time.Duration
+}
+
+// DefaultTimeouts are the Timeouts that a Client is constructed with.
+var DefaultTimeouts = Timeouts{
+	Handshake:    10 * time.Second,
+	FirstCommand: 10 * time.Second,
+}
+
+// SetTimeouts changes the Timeouts in effect. It returns ErrStarted if the
+// Client has already been started, either by Handshake, or by ReadMessage or
+// WriteMessage.
+func (c *Client) SetTimeouts(timeouts Timeouts) error {
+	c.smu.Lock()
+	defer c.smu.Unlock()
+
+	if c.state != stateNew {
+		return c.stateErr()
+	}
+
+	c.timeouts = timeouts
+
+	return nil
+}
+
+// readDeadliner is implemented by connections which support read deadlines,
+// such as net.Conn.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// awaitCommand sets the read deadline by which the client must have sent its
+// first command.
+func (c *Client) awaitCommand() {
+	d, ok := c.Conn.(readDeadliner)
+	if !ok || c.timeouts.FirstCommand <= 0 {
+		return
+	}
+
+	c.emu.Lock()
+	defer c.emu.Unlock()
+
+	c.awaiting = true
+	d.SetReadDeadline(time.Now().Add(c.timeouts.FirstCommand))
+}
+
+// commandOn clears the read deadline set by awaitCommand if the given message
+// is the first command received from the client.
+func (c *Client) commandOn(msg *chunk.Chunk) {
+	if id := msg.TypeId(); id != 0x14 && id != 0x11 {
+		return
+	}
+
+	c.emu.Lock()
+	defer c.emu.Unlock()
+
+	if c.awaiting {
+		c.awaiting = false
+		c.Conn.(readDeadliner).SetReadDeadline(time.Time{})
+	}
+}
+
+// commandErr returns ErrFirstCommandTimeout if the given error is a timeout
+// encountered while waiting for the first command, or the given error
+// otherwise.
+func (c *Client) commandErr(err error) error {
+	c.emu.Lock()
+	defer c.emu.Unlock()
+
+	if ne, ok := err.(net.Error); ok && ne.Timeout() && c.awaiting {
+		return ErrFirstCommandTimeout
+	}
+
+	return err
+}
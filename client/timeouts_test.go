@@ -0,0 +1,89 @@
+package client_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/rtmp/chunk"
+	"github.com/WatchBeam/rtmp/client"
+	"github.com/WatchBeam/rtmp/handshake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandshakesTimeOutWhenTheClientStalls(t *testing.T) {
+	server, peer := net.Pipe()
+	defer peer.Close()
+
+	c := client.New(server)
+	defer c.Close()
+
+	require.Nil(t, c.SetTimeouts(client.Timeouts{
+		Handshake: 10 * time.Millisecond,
+	}))
+
+	assert.Equal(t, handshake.TimeoutErr, c.Handshake())
+}
+
+func TestReadingTimesOutWithoutACommand(t *testing.T) {
+	server, peer := dial(t)
+	defer peer.Close()
+
+	c := client.New(server)
+	defer c.Close()
+
+	require.Nil(t, c.SetTimeouts(client.Timeouts{
+		FirstCommand: 10 * time.Millisecond,
+	}))
+
+	go handshakeAsPeer(t, peer)
+	_, err := c.ReadMessage()
+
+	assert.Equal(t, client.ErrFirstCommandTimeout, err)
+}
+
+func TestClientsAreTornDownWithoutACommand(t *testing.T) {
+	server, peer := dial(t)
+	defer peer.Close()
+
+	c := client.New(server)
+	defer c.Close()
+
+	require.Nil(t, c.SetTimeouts(client.Timeouts{
+		FirstCommand: 10 * time.Millisecond,
+	}))
+
+	go handshakeAsPeer(t, peer)
+	require.Nil(t, c.Handshake())
+
+	<-c.Done()
+
+	assert.Equal(t, client.ErrFirstCommandTimeout, c.Err())
+}
+
+func TestTheFirstCommandClearsTheDeadline(t *testing.T) {
+	server, peer := dial(t)
+	defer peer.Close()
+
+	c := client.New(server)
+	defer c.Close()
+
+	require.Nil(t, c.SetTimeouts(client.Timeouts{
+		FirstCommand: 10 * time.Millisecond,
+	}))
+
+	go func() {
+		handshakeAsPeer(t, peer)
+
+		w := chunk.NewWriter(peer, chunk.DefaultReadSize)
+		w.Write(ConnectChunk)
+		time.Sleep(30 * time.Millisecond)
+		w.Write(ConnectChunk)
+	}()
+
+	for i := 0; i < 2; i++ {
+		_, err := c.ReadMessage()
+		assert.Nil(t, err)
+	}
+}
@@ -31,3 +31,17 @@ func TestChunkersMarshalChunks(t *testing.T) {
 	assert.Equal(t, uint32(len(marshalled)), c.Header.MessageHeader.Length)
 	assert.Equal(t, marshalled, c.Data)
 }
+
+func TestResponsesMarshalTheirNames(t *testing.T) {
+	for name, m := range map[string]conn.Marshallable{
+		conn.OnFCPublishName:   new(conn.OnFCPublish),
+		conn.OnFCUnpublishName: new(conn.OnFCUnpublish),
+		conn.OnFCSubscribeName: new(conn.OnFCSubscribe),
+		conn.ErrorResponseType: new(conn.ErrorResponse),
+	} {
+		marshalled, err := m.Marshal()
+
+		assert.Nil(t, err)
+		assert.Contains(t, string(marshalled), name)
+	}
+}
@@ -11,12 +11,15 @@ import (
 var (
 	// DefaultParser is the primary singleton instance of the Parser type.
 	// It comes preloaded with all RTMP-related Receivable types, which
-	// currently include the connect, createStream, releaseStream, and
-	// FCPublish packets.
+	// currently include the connect, createStream, releaseStream,
+	// FCPublish, getStreamLength, FCUnpublish, FCSubscribe, deleteStream,
+	// closeStream and _checkbw packets, as well as the _result and _error
+	// replies. Commands with any other name are calls made through
+	// NetConnection.call, and are parsed into CallCommands.
 	//
 	// It is recommended that this be used as the primary parcer in any type
 	// that requires it.
-	DefaultParser Parser = NewParser(map[string]ReceviableFactory{
+	DefaultParser Parser = NewCallParser(map[string]ReceviableFactory{
 		"connect": func() Receivable {
 			return &ConnectCommand{
 				Metadata: amf0.NewObject(),
@@ -36,6 +39,30 @@ var (
 		"getStreamLength": func() Receivable {
 			return new(GetStreamLength)
 		},
+
+		"FCUnpublish": func() Receivable { return new(FCUnpublishCommand) },
+
+		"FCSubscribe": func() Receivable { return new(FCSubscribeCommand) },
+
+		"deleteStream": func() Receivable {
+			return new(DeleteStreamCommand)
+		},
+
+		"closeStream": func() Receivable { return new(CloseStreamCommand) },
+
+		"_checkbw": func() Receivable { return new(CheckBWCommand) },
+
+		SuccessfulResponseType: func() Receivable {
+			return &ResultReply{
+				Information: amf0.NewObject(),
+			}
+		},
+
+		ErrorResponseType: func() Receivable {
+			return &ErrorReply{
+				Information: amf0.NewObject(),
+			}
+		},
 	})
 )
 
@@ -53,6 +80,9 @@ type SimpleParser struct {
 	// ReceviableFactory so that it can be looked up later and instantiated
 	// quickly.
 	typs map[string]ReceviableFactory
+	// calls is true if commands whose name is not in typs are parsed into
+	// CallCommands, rather than rejected.
+	calls bool
 }
 
 var _ Parser = new(SimpleParser)
@@ -67,11 +97,22 @@ func NewParser(typs map[string]ReceviableFactory) *SimpleParser {
 	}
 }
 
+// NewCallParser returns a new instance of the Parser type in the same way as
+// NewParser, except that commands whose name is not in the given `typs` map are
+// parsed into CallCommands, rather than rejected.
+func NewCallParser(typs map[string]ReceviableFactory) *SimpleParser {
+	p := NewParser(typs)
+	p.calls = true
+
+	return p
+}
+
 // Parse implements the Parser.Parse method. It parses a Receivable type out of
 // the given AMF identifier (parsed from the io.Reader `r` as a source), or an
 // error in the following cases:
 //
-//   1) no corresponding command could be found
+//   1) no corresponding command could be found (unless the SimpleParser was
+//   returned by NewCallParser)
 //   2) an error occured during unmarshalling (see WatchBeam/rtmp)
 //
 // Otherwise the Receivable type is returned succesfully, and no error is
@@ -80,15 +121,103 @@ func (p *SimpleParser) Parse(name *amf0.String, r io.Reader) (Receivable, error)
 	str := string(*name)
 
 	factory := p.typs[str]
+	if factory == nil && p.calls {
+		factory = func() Receivable { return &CallCommand{Name: str} }
+	}
+
 	if factory == nil {
 		return nil, fmt.Errorf(
 			"rtmp/cmd/conn: unknown command name: %v", str)
 	}
 
 	v := factory()
-	if err := encoding.Unmarshal(r, v); err != nil {
+
+	var err error
+	if d, ok := v.(decoder); ok {
+		err = d.decode(r)
+	} else {
+		err = encoding.Unmarshal(r, v)
+	}
+
+	if err != nil {
 		return nil, err
 	}
 
 	return v, nil
 }
+
+// decoder is implemented by Receivables holding values whose AMF0 type is not
+// known ahead of time, which encoding.Unmarshal is unable to decode.
+type decoder interface {
+	// decode decodes the Receivable off of the given io.Reader.
+	decode(r io.Reader) error
+}
+
+// callHeader is the part of a command which follows its name.
+type callHeader struct {
+	TransactionId float64
+}
+
+// replyInformation is the part of a reply which follows its Properties.
+type replyInformation struct {
+	Information *amf0.Object
+}
+
+// decode implements decoder.decode. The rest of the values held by the command
+// are taken as its Arguments.
+func (c *CallCommand) decode(r io.Reader) error {
+	header := new(callHeader)
+	if err := encoding.Unmarshal(r, header); err != nil {
+		return err
+	}
+	c.TransactionId = header.TransactionId
+
+	obj, err := amf0.Decode(r)
+	if err != nil {
+		return err
+	}
+	c.CommandObject = obj
+
+	for {
+		arg, err := amf0.Decode(r)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		c.Arguments = append(c.Arguments, arg)
+	}
+}
+
+// decode implements decoder.decode.
+func (re *ResultReply) decode(r io.Reader) error {
+	return decodeReply(r, &re.TransactionId, &re.Properties, re.Information)
+}
+
+// decode implements decoder.decode.
+func (re *ErrorReply) decode(r io.Reader) error {
+	return decodeReply(r, &re.TransactionId, &re.Properties, re.Information)
+}
+
+// decodeReply decodes a _result or _error reply off of the given io.Reader,
+// whose Properties may be of any type (though they are usually null).
+func decodeReply(r io.Reader, id *float64, props *amf0.AmfType,
+	info *amf0.Object) error {
+
+	header := new(callHeader)
+	if err := encoding.Unmarshal(r, header); err != nil {
+		return err
+	}
+	*id = header.TransactionId
+
+	p, err := amf0.Decode(r)
+	if err != nil {
+		return err
+	}
+	*props = p
+
+	return encoding.Unmarshal(r, &replyInformation{info})
+}
@@ -70,3 +70,194 @@ func TestParseFailsWhenDataIsUnmarshalable(t *testing.T) {
 	assert.Nil(t, r)
 	assert.Equal(t, io.EOF, err)
 }
+
+func TestDefaultParserParsesPublishingCommands(t *testing.T) {
+	for _, c := range []struct {
+		Name     string
+		Payload  []byte
+		Expected conn.Receivable
+	}{
+		{
+			"FCUnpublish",
+			[]byte{
+				0x00, 0x40, 0x14, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 5
+				0x05,                            // null
+				0x02, 0x00, 0x03, 'k', 'e', 'y', // "key"
+			},
+			&conn.FCUnpublishCommand{TransactionId: 5, StreamKey: "key"},
+		},
+		{
+			"FCSubscribe",
+			[]byte{
+				0x00, 0x40, 0x18, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 6
+				0x05,                            // null
+				0x02, 0x00, 0x03, 'k', 'e', 'y', // "key"
+			},
+			&conn.FCSubscribeCommand{TransactionId: 6, StreamKey: "key"},
+		},
+		{
+			"deleteStream",
+			[]byte{
+				0x00, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 7
+				0x05,                                                 // null
+				0x00, 0x3f, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 1
+			},
+			&conn.DeleteStreamCommand{TransactionId: 7, StreamId: 1},
+		},
+		{
+			"closeStream",
+			[]byte{
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 0
+				0x05, // null
+			},
+			&conn.CloseStreamCommand{TransactionId: 0},
+		},
+		{
+			"_checkbw",
+			[]byte{
+				0x00, 0x40, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 3
+				0x05, // null
+			},
+			&conn.CheckBWCommand{TransactionId: 3},
+		},
+		{
+			"_result",
+			[]byte{
+				0x00, 0x3f, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 1
+				0x03, // {"fmsVer": "FMS/3"}
+				0x00, 0x06, 'f', 'm', 's', 'V', 'e', 'r',
+				0x02, 0x00, 0x05, 'F', 'M', 'S', '/', '3',
+				0x00, 0x00, 0x09,
+				0x03, // {"level": "status"}
+				0x00, 0x05, 'l', 'e', 'v', 'e', 'l',
+				0x02, 0x00, 0x06, 's', 't', 'a', 't', 'u', 's',
+				0x00, 0x00, 0x09,
+			},
+			&conn.ResultReply{
+				TransactionId: 1,
+				Properties:    NewObject("fmsVer", "FMS/3"),
+				Information:   NewObject("level", "status"),
+			},
+		},
+		{
+			"_result",
+			[]byte{
+				0x00, 0x40, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 4
+				0x05, // null
+				0x03, // {"level": "status"}
+				0x00, 0x05, 'l', 'e', 'v', 'e', 'l',
+				0x02, 0x00, 0x06, 's', 't', 'a', 't', 'u', 's',
+				0x00, 0x00, 0x09,
+			},
+			&conn.ResultReply{
+				TransactionId: 4,
+				Properties:    new(amf0.Null),
+				Information:   NewObject("level", "status"),
+			},
+		},
+		{
+			"_error",
+			[]byte{
+				0x00, 0x40, 0x14, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 5
+				0x05, // null
+				0x03, // {"level": "error"}
+				0x00, 0x05, 'l', 'e', 'v', 'e', 'l',
+				0x02, 0x00, 0x05, 'e', 'r', 'r', 'o', 'r',
+				0x00, 0x00, 0x09,
+			},
+			&conn.ErrorReply{
+				TransactionId: 5,
+				Properties:    new(amf0.Null),
+				Information:   NewObject("level", "error"),
+			},
+		},
+		{
+			"_error",
+			[]byte{
+				0x00, 0x40, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 2
+				0x03, // {}
+				0x00, 0x00, 0x09,
+				0x03, // {"level": "error"}
+				0x00, 0x05, 'l', 'e', 'v', 'e', 'l',
+				0x02, 0x00, 0x05, 'e', 'r', 'r', 'o', 'r',
+				0x00, 0x00, 0x09,
+			},
+			&conn.ErrorReply{
+				TransactionId: 2,
+				Properties:    amf0.NewObject(),
+				Information:   NewObject("level", "error"),
+			},
+		},
+	} {
+		r, err := conn.DefaultParser.Parse(
+			amf0.NewString(c.Name),
+			bytes.NewReader(c.Payload),
+		)
+
+		assert.Nil(t, err, c.Name)
+		if !assert.IsType(t, c.Expected, r, c.Name) {
+			continue
+		}
+
+		switch expected := c.Expected.(type) {
+		case *conn.FCUnpublishCommand:
+			typ := r.(*conn.FCUnpublishCommand)
+			assert.Equal(t, expected.TransactionId, typ.TransactionId)
+			assert.Equal(t, expected.StreamKey, typ.StreamKey)
+		case *conn.FCSubscribeCommand:
+			typ := r.(*conn.FCSubscribeCommand)
+			assert.Equal(t, expected.TransactionId, typ.TransactionId)
+			assert.Equal(t, expected.StreamKey, typ.StreamKey)
+		case *conn.DeleteStreamCommand:
+			typ := r.(*conn.DeleteStreamCommand)
+			assert.Equal(t, expected.TransactionId, typ.TransactionId)
+			assert.Equal(t, expected.StreamId, typ.StreamId)
+		case *conn.CloseStreamCommand:
+			typ := r.(*conn.CloseStreamCommand)
+			assert.Equal(t, expected.TransactionId, typ.TransactionId)
+		case *conn.CheckBWCommand:
+			typ := r.(*conn.CheckBWCommand)
+			assert.Equal(t, expected.TransactionId, typ.TransactionId)
+		case *conn.ResultReply:
+			typ := r.(*conn.ResultReply)
+			assert.Equal(t, expected.TransactionId, typ.TransactionId)
+			assert.Equal(t, expected.Properties, typ.Properties)
+			assert.Equal(t, expected.Information, typ.Information)
+		case *conn.ErrorReply:
+			typ := r.(*conn.ErrorReply)
+			assert.Equal(t, expected.TransactionId, typ.TransactionId)
+			assert.Equal(t, expected.Properties, typ.Properties)
+			assert.Equal(t, expected.Information, typ.Information)
+		}
+	}
+}
+
+func TestDefaultParserParsesCallsToUnknownProcedures(t *testing.T) {
+	r, err := conn.DefaultParser.Parse(
+		amf0.NewString("getUserList"),
+		bytes.NewReader([]byte{
+			0x00, 0x40, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 3
+			0x05,                            // null
+			0x02, 0x00, 0x03, 'a', 'l', 'l', // "all"
+			0x02, 0x00, 0x02, 'o', 'k', // "ok"
+		}),
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &conn.CallCommand{
+		Name:          "getUserList",
+		TransactionId: 3,
+		CommandObject: new(amf0.Null),
+		Arguments: []amf0.AmfType{
+			amf0.NewString("all"), amf0.NewString("ok"),
+		},
+	}, r)
+}
+
+// NewObject returns a new *amf0.Object holding the given string property.
+func NewObject(key, val string) *amf0.Object {
+	obj := amf0.NewObject()
+	obj.Add(key, amf0.NewString(val))
+
+	return obj
+}
@@ -9,6 +9,17 @@ const (
 	// SuccessfulResponseType is the respnse type string attached to
 	// successful responses.
 	SuccessfulResponseType = "_result"
+	// ErrorResponseType is the response type string attached to failed
+	// responses.
+	ErrorResponseType = "_error"
+	// OnBWDoneName is the name of the onBWDone command.
+	OnBWDoneName = "onBWDone"
+	// OnFCPublishName is the name of the onFCPublish command.
+	OnFCPublishName = "onFCPublish"
+	// OnFCUnpublishName is the name of the onFCUnpublish command.
+	OnFCUnpublishName = "onFCUnpublish"
+	// OnFCSubscribeName is the name of the onFCSubscribe command.
+	OnFCSubscribeName = "onFCSubscribe"
 )
 
 // Type Marshallable is used to tag certain Responses as being able to be sent.
@@ -30,6 +41,54 @@ type ConnectResponse struct {
 	Information   amf0.Object
 }
 
+// OnBWDone is the command sent to a client once its bandwidth has been
+// "checked", which some clients (such as FMLE) wait for after connecting.
+type OnBWDone struct {
+	Name          string
+	TransactionId float64
+	_             *amf0.Null
+}
+
+// OnFCPublish is the command sent in response to an FCPublishCommand, which
+// encoders (such as FMLE) wait for before they start publishing. Information
+// usually holds the "NetStream.Publish.Start" code, and the stream key as its
+// description.
+type OnFCPublish struct {
+	Name          string
+	TransactionId float64
+	_             *amf0.Null
+	Information   amf0.Object
+}
+
+// OnFCUnpublish is the command sent in response to an FCUnpublishCommand.
+// Information usually holds the "NetStream.Unpublish.Success" code, and the
+// stream key as its description.
+type OnFCUnpublish struct {
+	Name          string
+	TransactionId float64
+	_             *amf0.Null
+	Information   amf0.Object
+}
+
+// OnFCSubscribe is the command sent in response to an FCSubscribeCommand.
+// Information usually holds the "NetStream.Play.Start" code, and the stream
+// key as its description.
+type OnFCSubscribe struct {
+	Name          string
+	TransactionId float64
+	_             *amf0.Null
+	Information   amf0.Object
+}
+
+// ErrorResponse is sent in response to a command which failed, such as a call
+// to an unknown procedure.
+type ErrorResponse struct {
+	ResponseType  string
+	TransactionId float64
+	_             *amf0.Null
+	Information   amf0.Object
+}
+
 // Marshal implements Marshallable.Marshal.
 func (r *CreateStreamResponse) Marshal() ([]byte, error) {
 	r.ResponseType = SuccessfulResponseType
@@ -41,3 +100,33 @@ func (r *ConnectResponse) Marshal() ([]byte, error) {
 	r.ResponseType = SuccessfulResponseType
 	return encoding.Marshal(r)
 }
+
+// Marshal implements Marshallable.Marshal.
+func (r *OnBWDone) Marshal() ([]byte, error) {
+	r.Name = OnBWDoneName
+	return encoding.Marshal(r)
+}
+
+// Marshal implements Marshallable.Marshal.
+func (r *OnFCPublish) Marshal() ([]byte, error) {
+	r.Name = OnFCPublishName
+	return encoding.Marshal(r)
+}
+
+// Marshal implements Marshallable.Marshal.
+func (r *OnFCUnpublish) Marshal() ([]byte, error) {
+	r.Name = OnFCUnpublishName
+	return encoding.Marshal(r)
+}
+
+// Marshal implements Marshallable.Marshal.
+func (r *OnFCSubscribe) Marshal() ([]byte, error) {
+	r.Name = OnFCSubscribeName
+	return encoding.Marshal(r)
+}
+
+// Marshal implements Marshallable.Marshal.
+func (r *ErrorResponse) Marshal() ([]byte, error) {
+	r.ResponseType = ErrorResponseType
+	return encoding.Marshal(r)
+}
@@ -45,6 +45,8 @@ type NetConn struct {
 	errs chan error
 	// closer is a channel written to when the Listen operation should halt.
 	closer chan struct{}
+	// done is closed once the Listen operation has halted.
+	done chan struct{}
 }
 
 // NewNetConnection returns a new instance of the NetConn type initialized with
@@ -60,6 +62,7 @@ func NewNetConnection(chunks <-chan *chunk.Chunk, writer chunk.Writer) *NetConn
 		out:         make(chan Marshallable),
 		errs:        make(chan error),
 		closer:      make(chan struct{}),
+		done:        make(chan struct{}),
 	}
 }
 
@@ -72,8 +75,16 @@ func (n *NetConn) In() <-chan Receivable { return n.in }
 func (n *NetConn) Out() chan<- Marshallable { return n.out }
 
 // Close halts the Listen operation after the current item has finished
-// processing.
-func (n *NetConn) Close() { n.closer <- struct{}{} }
+// processing. It is safe to call once Listen has already halted.
+func (n *NetConn) Close() {
+	select {
+	case n.closer <- struct{}{}:
+	case <-n.done:
+	}
+}
+
+// Done returns a channel which is closed once the Listen operation has halted.
+func (n *NetConn) Done() <-chan struct{} { return n.done }
 
 // Errs returns a read-only channel of errors which were encountered during the
 // Listen operation (see below).
@@ -89,44 +100,86 @@ func (n *NetConn) Errs() <-chan error { return n.errs }
 //    over the chunk stream, writing an error to Errs() if one was encountered.
 //
 // Listen terminates when the closer channel can be read (accomplished by
-// calling Close()).
+// calling Close()), or when the incoming channel of chunks is closed.
 //
 // Listen runs within its own goroutine.
 func (n *NetConn) Listen() {
+	defer close(n.done)
+
 	for {
 		select {
-		case c := <-n.chunkStream:
-			buf := bytes.NewBuffer(c.Data)
+		case c, ok := <-n.chunkStream:
+			if !ok {
+				return
+			}
 
-			name, err := amf0.Decode(buf)
+			r, err := n.receive(c)
 			if err != nil {
-				n.errs <- err
-				continue
-			}
+				if !n.fail(err) {
+					return
+				}
 
-			nameStr, ok := name.(*amf0.String)
-			if !ok {
-				n.errs <- fmt.Errorf("rtmp/conn: wrong type for AMF header: %T (expected amf0.String)", name)
 				continue
 			}
 
-			if r, err := n.parser.Parse(nameStr, buf); err != nil {
-				n.errs <- err
-			} else {
-				n.in <- r
+			if !n.emit(r) {
+				return
 			}
 		case out := <-n.out:
-			c, err := n.chunker.Chunk(out)
-			if err != nil {
-				n.errs <- err
-				continue
-			}
-
-			if err = n.writer.Write(c); err != nil {
-				n.errs <- err
+			if err := n.send(out); err != nil && !n.fail(err) {
+				return
 			}
 		case <-n.closer:
 			return
 		}
 	}
 }
+
+// receive decodes the Receivable held by the given chunk.
+func (n *NetConn) receive(c *chunk.Chunk) (Receivable, error) {
+	buf := bytes.NewBuffer(c.Data)
+
+	name, err := amf0.Decode(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	nameStr, ok := name.(*amf0.String)
+	if !ok {
+		return nil, fmt.Errorf("rtmp/conn: wrong type for AMF header: %T (expected amf0.String)", name)
+	}
+
+	return n.parser.Parse(nameStr, buf)
+}
+
+// send chunks the given Marshallable and writes it to the chunk.Writer.
+func (n *NetConn) send(out Marshallable) error {
+	c, err := n.chunker.Chunk(out)
+	if err != nil {
+		return err
+	}
+
+	return n.writer.Write(c)
+}
+
+// emit pushes the given Receivable over the in channel, returning false if the
+// NetConn was closed while waiting for it to be read.
+func (n *NetConn) emit(r Receivable) bool {
+	select {
+	case n.in <- r:
+		return true
+	case <-n.closer:
+		return false
+	}
+}
+
+// fail pushes the given error over the errs channel, returning false if the
+// NetConn was closed while waiting for it to be read.
+func (n *NetConn) fail(err error) bool {
+	select {
+	case n.errs <- err:
+		return true
+	case <-n.closer:
+		return false
+	}
+}
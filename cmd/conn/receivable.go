@@ -35,8 +35,86 @@ type GetStreamLength struct {
 	PlayPath string
 }
 
+// FCUnpublishCommand is sent by encoders which sent an FCPublishCommand once
+// they stop publishing to the given stream key.
+type FCUnpublishCommand struct {
+	TransactionId float64
+	Nil           *amf0.Null
+	StreamKey     string
+}
+
+// FCSubscribeCommand is sent by some players (such as those talking to a CDN)
+// before playing the given stream key.
+type FCSubscribeCommand struct {
+	TransactionId float64
+	Nil           *amf0.Null
+	StreamKey     string
+}
+
+// DeleteStreamCommand is sent to delete the stream with the given ID, as
+// previously allocated in response to a CreateStreamCommand.
+type DeleteStreamCommand struct {
+	TransactionId float64
+	Nil           *amf0.Null
+	StreamId      float64
+}
+
+// CloseStreamCommand is sent by clients which stop publishing (or playing)
+// without deleting the stream that they were publishing to.
+type CloseStreamCommand struct {
+	TransactionId float64
+	Nil           *amf0.Null
+}
+
+// CheckBWCommand is sent by clients (such as FFmpeg) which received an
+// OnBWDone, to check their bandwidth in return.
+type CheckBWCommand struct {
+	TransactionId float64
+	Nil           *amf0.Null
+}
+
+// CallCommand is a remote procedure call made by the client through
+// NetConnection.call, which carries the name of the procedure rather than that
+// of a command (see NewCallParser). Calls to a procedure that the application
+// does not provide should be answered with an ErrorResponse carrying the same
+// TransactionId.
+type CallCommand struct {
+	// Name is the name of the procedure called.
+	Name          string
+	TransactionId float64
+	// CommandObject is the command object of the call, which is usually
+	// null.
+	CommandObject amf0.AmfType
+	// Arguments holds the arguments passed to the procedure, in order.
+	Arguments []amf0.AmfType
+}
+
+// ResultReply is sent by clients in reply to a successful call, such as
+// one made by the server. Properties is usually null.
+type ResultReply struct {
+	TransactionId float64
+	Properties    amf0.AmfType
+	Information   *amf0.Object
+}
+
+// ErrorReply is sent by clients in reply to a failed call. Properties is
+// usually null.
+type ErrorReply struct {
+	TransactionId float64
+	Properties    amf0.AmfType
+	Information   *amf0.Object
+}
+
 func (_ *ConnectCommand) CanReceive() bool      { return true }
 func (_ *CreateStreamCommand) CanReceive() bool { return true }
 func (_ *ReleaseCommand) CanReceive() bool      { return true }
 func (_ *FCPublishCommand) CanReceive() bool    { return true }
 func (_ *GetStreamLength) CanReceive() bool     { return true }
+func (_ *FCUnpublishCommand) CanReceive() bool  { return true }
+func (_ *FCSubscribeCommand) CanReceive() bool  { return true }
+func (_ *DeleteStreamCommand) CanReceive() bool { return true }
+func (_ *CloseStreamCommand) CanReceive() bool  { return true }
+func (_ *CheckBWCommand) CanReceive() bool      { return true }
+func (_ *CallCommand) CanReceive() bool         { return true }
+func (_ *ResultReply) CanReceive() bool         { return true }
+func (_ *ErrorReply) CanReceive() bool          { return true }
@@ -13,6 +13,14 @@ func TestRelevantCommandsImplementReceivable(t *testing.T) {
 		new(conn.ReleaseCommand),
 		new(conn.FCPublishCommand),
 		new(conn.GetStreamLength),
+		new(conn.FCUnpublishCommand),
+		new(conn.FCSubscribeCommand),
+		new(conn.DeleteStreamCommand),
+		new(conn.CloseStreamCommand),
+		new(conn.CheckBWCommand),
+		new(conn.CallCommand),
+		new(conn.ResultReply),
+		new(conn.ErrorReply),
 	} {
 		if _, receivable := c.(conn.Receivable); !receivable {
 			t.Fatalf("type %T does not implement Receivable", c)
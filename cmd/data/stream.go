@@ -28,6 +28,8 @@ type Stream struct {
 	// message is read over this channel, the Stream is expected to clean up
 	// after itself.
 	closer chan struct{}
+	// done is closed once the Stream has cleaned up after itself.
+	done chan struct{}
 }
 
 // NewStream creates and returns a pointer to a new instance of the Stream type.
@@ -43,6 +45,7 @@ func NewStream(chunks chan *chunk.Chunk, writer chunk.Writer) *Stream {
 		out:    make(chan Data),
 		errs:   make(chan error),
 		closer: make(chan struct{}),
+		done:   make(chan struct{}),
 	}
 }
 
@@ -61,8 +64,18 @@ func (s *Stream) Out() <-chan Data { return s.out }
 func (s *Stream) Errs() <-chan error { return s.errs }
 
 // Close closes the `*data.Stream`, causing it to stop listening as well as
-// close all internal channels.
-func (s *Stream) Close() { s.closer <- struct{}{} }
+// close all internal channels. It is safe to call once the Stream has already
+// stopped.
+func (s *Stream) Close() {
+	select {
+	case s.closer <- struct{}{}:
+	case <-s.done:
+	}
+}
+
+// Done returns a channel which is closed once the Stream has stopped listening,
+// either because it was closed, or because its chunk stream was.
+func (s *Stream) Done() <-chan struct{} { return s.done }
 
 // SetParser sets the intenral parser used by this Stream. This method is _not_
 // safe to use between multiple goroutines, and should be used with caution.
@@ -77,7 +90,8 @@ func (s *Stream) SetParser(p Parser) { s.parser = p }
 // it using the Data.Marshal function, and then sends it over the chunk stream.
 //
 // Recv also wathces the internal closer channel so that this `*data.Stream` may
-// clean up after itself post-closing.
+// clean up after itself post-closing. It does the same once the chunk stream
+// has been closed.
 //
 // Recv runs within its own goroutine.
 func (s *Stream) Recv() {
@@ -85,31 +99,66 @@ func (s *Stream) Recv() {
 		close(s.in)
 		close(s.out)
 		close(s.errs)
-		close(s.closer)
+		close(s.done)
 	}()
 
 	for {
 		select {
-		case chunk := <-s.chunks:
-			data, err := s.parser.Parse(chunk)
-			if err != nil {
-				s.errs <- err
-				continue
+		case chunk, ok := <-s.chunks:
+			if !ok {
+				return
 			}
 
-			s.out <- data
-		case in := <-s.in:
-			c, err := in.Marshal()
+			data, err := s.parser.Parse(chunk)
 			if err != nil {
-				s.errs <- err
+				if !s.fail(err) {
+					return
+				}
+
 				continue
 			}
 
-			if err = s.writer.Write(c); err != nil {
-				s.errs <- err
+			if !s.emit(data) {
+				return
+			}
+		case in := <-s.in:
+			if err := s.send(in); err != nil && !s.fail(err) {
+				return
 			}
 		case <-s.closer:
 			return
 		}
 	}
 }
+
+// send marshals the given Data and writes it to the chunk.Writer.
+func (s *Stream) send(in Data) error {
+	c, err := in.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return s.writer.Write(c)
+}
+
+// emit pushes the given Data over the out channel, returning false if the
+// Stream was closed while waiting for it to be read.
+func (s *Stream) emit(data Data) bool {
+	select {
+	case s.out <- data:
+		return true
+	case <-s.closer:
+		return false
+	}
+}
+
+// fail pushes the given error over the errs channel, returning false if the
+// Stream was closed while waiting for it to be read.
+func (s *Stream) fail(err error) bool {
+	select {
+	case s.errs <- err:
+		return true
+	case <-s.closer:
+		return false
+	}
+}
@@ -12,11 +12,16 @@ import (
 // uses the Gate mechanism to dispatch them appropriately to each sub-package.
 type Manager struct {
 	// chunks is the incoming chunk stream to feed from. In most normal
-	// cases, this will be a *chunk.MultiStream, but either works.
+	// cases, this will be a *chunk.OrderedStream, but either works.
 	chunks chunk.Stream
 	// closer is a channel which is written to when it is time to close the
 	// Manager.
 	closer chan struct{}
+	// done is closed once the Dispatch loop has returned.
+	done chan struct{}
+	// hook, if set, is called with each incoming chunk before it is
+	// dispatched.
+	hook func(*chunk.Chunk)
 
 	// channels maps Gates to the channel which they are gating.
 	channels map[Gate]chan<- *chunk.Chunk
@@ -50,6 +55,7 @@ func New(chunks chunk.Stream, writer chunk.Writer) *Manager {
 	return &Manager{
 		chunks: chunks,
 		closer: make(chan struct{}),
+		done:   make(chan struct{}),
 
 		channels: map[Gate]chan<- *chunk.Chunk{
 			NetConnGate:    netConnChunks,
@@ -72,7 +78,24 @@ func (m *Manager) NetStream() *stream.NetStream { return m.netStream }
 // DataStream returns the DataStream that is associated with this client.
 func (m *Manager) DataStream() *data.Stream { return m.dataStream }
 
-func (m *Manager) Close() { m.closer <- struct{}{} }
+// SetHook sets a function which is called from the Dispatch loop with each
+// incoming chunk, before that chunk is dispatched to any of the children. It
+// must be called before Dispatch.
+func (m *Manager) SetHook(hook func(*chunk.Chunk)) { m.hook = hook }
+
+// Close stops the Dispatch loop. It is safe to call once the loop has already
+// returned.
+func (m *Manager) Close() {
+	select {
+	case m.closer <- struct{}{}:
+	case <-m.done:
+	}
+}
+
+// Done returns a channel which is closed once the Dispatch loop has returned,
+// either because the Manager was closed, or because the incoming chunk stream
+// was closed (such as when the connection has been lost).
+func (m *Manager) Done() <-chan struct{} { return m.done }
 
 // Dispatch handles the dispatch loop responsible for processing all incoming
 // chunks that are received over the given chunk.Stream (see `New()`).
@@ -90,39 +113,68 @@ func (m *Manager) Close() { m.closer <- struct{}{} }
 //   distributed more than once, but in most cases, the set of channels given is
 //   mutually exclusive.
 //
-//   3) Respond to the `Close()` operation. If close is passed, then the loop
-//   will terminate and, if manageChildren is set to true, the children will be
-//   closed as well.
+//   3) Respond to the `Close()` operation, or the incoming chunk stream being
+//   closed. In either case, the loop will terminate and close the channels of
+//   all children, which stops them as well. If manageChildren is set to true,
+//   the children are closed before Done() is.
 //
 // Dispatch runs within its own goroutine.
 func (m *Manager) Dispatch(manageChildren bool) {
 	if manageChildren {
 		m.startChildren()
-		defer m.cleanupChildren()
 	}
 
 	defer func() {
-		close(m.closer)
-
 		for _, ch := range m.channels {
 			close(ch)
 		}
+
+		if manageChildren {
+			m.cleanupChildren()
+		}
+
+		close(m.done)
 	}()
 
 	for {
 		select {
-		case c := <-m.chunks.In():
-			for gate, chunks := range m.channels {
-				if gate.Open(c) {
-					chunks <- c
-				}
+		case c, ok := <-m.chunks.In():
+			if !ok {
+				return
+			}
+
+			if m.hook != nil {
+				m.hook(c)
+			}
+
+			if !m.dispatch(c) {
+				return
 			}
 		case <-m.closer:
-			break
+			return
 		}
 	}
 }
 
+// dispatch sends the given chunk over the channel of every Gate that is open
+// for it, returning false if the Manager was closed while waiting for one of
+// them to be read.
+func (m *Manager) dispatch(c *chunk.Chunk) bool {
+	for gate, chunks := range m.channels {
+		if !gate.Open(c) {
+			continue
+		}
+
+		select {
+		case chunks <- c:
+		case <-m.closer:
+			return false
+		}
+	}
+
+	return true
+}
+
 // startChildren spawns all of the `Listen` subroutines for each managed child.
 func (m *Manager) startChildren() {
 	go m.netConn.Listen()
@@ -130,9 +182,15 @@ func (m *Manager) startChildren() {
 	go m.dataStream.Recv()
 }
 
-// cleanupChildren stops all of the `Listen` subroutines for each managed child.
+// cleanupChildren stops all of the `Listen` subroutines for each managed child,
+// and waits for them to finish.
 func (m *Manager) cleanupChildren() {
 	m.netConn.Close()
 	m.netStream.Close()
 	m.dataStream.Close()
+
+	<-m.netConn.Done()
+	<-m.netStream.Done()
+	<-m.dataStream.Done()
 }
+
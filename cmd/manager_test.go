@@ -45,3 +45,41 @@ func TestManagerDispatchesToMatchingChannels(t *testing.T) {
 		reflect.ValueOf(c).Pointer(),
 		reflect.ValueOf(<-c2).Pointer())
 }
+
+func TestManagerStopsWhenTheChunkStreamCloses(t *testing.T) {
+	cs := &MockChunkStream{make(chan *chunk.Chunk)}
+	c1 := make(chan *chunk.Chunk)
+
+	m := New(cs, nil)
+	m.channels = map[Gate]chan<- *chunk.Chunk{new(TrueGate): c1}
+
+	go m.Dispatch(false)
+
+	close(cs.C)
+	<-m.Done()
+
+	_, open := <-c1
+	assert.False(t, open)
+
+	m.Close()
+}
+
+func TestManagerCallsTheHookBeforeDispatching(t *testing.T) {
+	c := new(chunk.Chunk)
+
+	cs := &MockChunkStream{make(chan *chunk.Chunk)}
+	c1 := make(chan *chunk.Chunk)
+	hooked := make(chan *chunk.Chunk, 1)
+
+	m := New(cs, nil)
+	m.channels = map[Gate]chan<- *chunk.Chunk{new(TrueGate): c1}
+	m.SetHook(func(c *chunk.Chunk) { hooked <- c })
+
+	go m.Dispatch(false)
+	defer m.Close()
+
+	cs.C <- c
+
+	assert.Equal(t, c, <-hooked)
+	assert.Equal(t, c, <-c1)
+}
@@ -36,6 +36,8 @@ type NetStream struct {
 	// closer is a channel written to when the Listen operation should be
 	// closed.
 	closer chan struct{}
+	// done is closed once the Listen operation has been closed.
+	done chan struct{}
 	// errs is a chnanel written to whenever an error is encountered during
 	// the Listen goroutine.
 	errs chan error
@@ -56,6 +58,7 @@ func New(chunks <-chan *chunk.Chunk, writer chunk.Writer) *NetStream {
 		in:       make(chan Command),
 		statuses: make(chan *Status),
 		closer:   make(chan struct{}),
+		done:     make(chan struct{}),
 		errs:     make(chan error),
 	}
 }
@@ -75,8 +78,17 @@ func (n *NetStream) Errs() <-chan error { return n.errs }
 // Close closes the Listen routine. Calling this function blocks until the
 // Listen routine has entered a closing state. Should this function be called
 // while a parse or send operation is taking place, then that operation will
-// finish before the close operation takes place immediately afterwords.
-func (n *NetStream) Close() { n.closer <- struct{}{} }
+// finish before the close operation takes place immediately afterwords. It is
+// safe to call once the Listen routine has already closed.
+func (n *NetStream) Close() {
+	select {
+	case n.closer <- struct{}{}:
+	case <-n.done:
+	}
+}
+
+// Done returns a channel which is closed once the Listen routine has closed.
+func (n *NetStream) Done() <-chan struct{} { return n.done }
 
 // Listen loops infinitely, managing the incoming and outgoing channel of chunks
 // on the chunk stream shared between the server and client.
@@ -85,7 +97,8 @@ func (n *NetStream) Close() { n.closer <- struct{}{} }
 //  - Parse incoming chunks, returning errors when they are unparsable.
 //  - Serialize outgoing `onStatus` commands, returning an error when they are
 //    either unserializable, or unwriteable.
-//  - Respond to the `Close()` operation by closing all output channels.
+//  - Respond to the `Close()` operation, or the incoming channel of chunks
+//    being closed, by closing all output channels.
 //
 // Listen runs within its own goroutine, and any errors encountered while
 // running are sent over the internal errs channel, accessible from the `Errs()`
@@ -95,32 +108,67 @@ func (n *NetStream) Listen() {
 		close(n.statuses)
 		close(n.in)
 		close(n.errs)
-		close(n.closer)
+		close(n.done)
 	}()
 
 L:
 	for {
 		select {
-		case chunk := <-n.chunks:
-			cmd, err := n.parser.Parse(bytes.NewReader(chunk.Data))
-			if err != nil {
-				n.errs <- err
-				continue
+		case chunk, ok := <-n.chunks:
+			if !ok {
+				break L
 			}
 
-			n.in <- cmd
-		case st := <-n.statuses:
-			c, err := st.AsChunk()
+			cmd, err := n.parser.Parse(bytes.NewReader(chunk.Data))
 			if err != nil {
-				n.errs <- err
+				if !n.fail(err) {
+					break L
+				}
+
 				continue
 			}
 
-			if err = n.writer.Write(c); err != nil {
-				n.errs <- err
+			if !n.emit(cmd) {
+				break L
+			}
+		case st := <-n.statuses:
+			if err := n.send(st); err != nil && !n.fail(err) {
+				break L
 			}
 		case <-n.closer:
 			break L
 		}
 	}
 }
+
+// send writes the given Status to the chunk.Writer.
+func (n *NetStream) send(st *Status) error {
+	c, err := st.AsChunk()
+	if err != nil {
+		return err
+	}
+
+	return n.writer.Write(c)
+}
+
+// emit pushes the given Command over the in channel, returning false if the
+// NetStream was closed while waiting for it to be read.
+func (n *NetStream) emit(cmd Command) bool {
+	select {
+	case n.in <- cmd:
+		return true
+	case <-n.closer:
+		return false
+	}
+}
+
+// fail pushes the given error over the errs channel, returning false if the
+// NetStream was closed while waiting for it to be read.
+func (n *NetStream) fail(err error) bool {
+	select {
+	case n.errs <- err:
+		return true
+	case <-n.closer:
+		return false
+	}
+}
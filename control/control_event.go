@@ -2,8 +2,11 @@ package control
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 	"io/ioutil"
+
+	"github.com/WatchBeam/rtmp/spec"
 )
 
 // EventType is a const type that wraps the uint16 base type to represent a
@@ -12,16 +15,79 @@ import (
 type EventType uint16
 
 const (
-	StreamBegin     EventType = 0
-	SetBufferLength EventType = 3
+	EventStreamBegin       EventType = 0
+	EventStreamEOF         EventType = 1
+	EventStreamDry         EventType = 2
+	EventSetBufferLength   EventType = 3
+	EventStreamIsRecorded  EventType = 4
+	EventPingRequest       EventType = 6
+	EventPingResponse      EventType = 7
+	EventSWFVerifyRequest  EventType = 26
+	EventSWFVerifyResponse EventType = 27
+)
+
+var (
+	// Events is a list of all User Control Message events defined in the
+	// RTMP specification, along with the SWF verification events.
+	Events []UserEvent = []UserEvent{
+		&StreamBegin{},
+		&StreamEOF{},
+		&StreamDry{},
+		&SetBufferLength{},
+		&StreamIsRecorded{},
+		&PingRequest{},
+		&PingResponse{},
+		&SWFVerifyRequest{},
+		&SWFVerifyResponse{},
+	}
 )
 
-// Event encapsulates any event that is sent over the control stream.
-//
-// NOTE: this is a temporary type, eventually it will be replaced with
-// individual types implementing both Control and Event interfaces. The current
-// structure of the parser/identifier does not easily allow for this, so some
-// more heavy lifting will have to occur before this gets further attention.
+// UserEvent is a Control sequence holding a single, typed, User Control Message
+// event (type ID 4, see section 7.1.7 of the RTMP specification). Its Read and
+// Write functions handle the entire body of the control sequence, including
+// the EventType that precedes the event data.
+type UserEvent interface {
+	Control
+
+	// EventType returns the EventType of this event.
+	EventType() EventType
+}
+
+// UnexpectedEventType is an Error representing a scenario where a UserEvent
+// reads the body of a User Control Message holding another type of event.
+type UnexpectedEventType EventType
+
+var _ error = new(UnexpectedEventType)
+
+// Error implements the `func Error` in the `type error interface`.
+func (e UnexpectedEventType) Error() string {
+	return fmt.Sprintf("control: unexpected event type (%v)", uint16(e))
+}
+
+// readEventType reads the EventType at the start of a User Control Message,
+// returning an UnexpectedEventType error if it is not the expected one.
+func readEventType(r io.Reader, expected EventType) error {
+	buf, err := spec.ReadBytes(r, 2)
+	if err != nil {
+		return err
+	}
+
+	if typ := EventType(spec.Uint16(buf)); typ != expected {
+		return UnexpectedEventType(typ)
+	}
+
+	return nil
+}
+
+// writeEventType writes the EventType at the start of a User Control Message.
+func writeEventType(w io.Writer, typ EventType) error {
+	_, err := spec.PutUint16(uint16(typ), w)
+	return err
+}
+
+// Event encapsulates any event that is sent over the control stream, holding
+// its body as it was received. The DefaultParser only returns an Event for
+// event types that do not have a UserEvent of their own (see Events).
 type Event struct {
 	// Type is the event type of the event.
 	Type EventType
@@ -0,0 +1,46 @@
+package control
+
+import "io"
+
+// PingRequest is sent by the server to test whether the client is reachable.
+// The client answers with a PingResponse holding the same Timestamp.
+type PingRequest struct {
+	// Timestamp is the local server time when the request was sent.
+	Timestamp uint32
+}
+
+var _ UserEvent = new(PingRequest)
+
+func (e *PingRequest) TypeId() byte { return 0x4 }
+
+func (e *PingRequest) EventType() EventType { return EventPingRequest }
+
+func (e *PingRequest) Read(r io.Reader) (err error) {
+	e.Timestamp, err = readUint32Event(r, e.EventType())
+	return err
+}
+
+func (e *PingRequest) Write(w io.Writer) error {
+	return writeUint32Event(w, e.EventType(), e.Timestamp)
+}
+
+// PingResponse is sent by the client in response to a PingRequest.
+type PingResponse struct {
+	// Timestamp is the Timestamp of the PingRequest being answered.
+	Timestamp uint32
+}
+
+var _ UserEvent = new(PingResponse)
+
+func (e *PingResponse) TypeId() byte { return 0x4 }
+
+func (e *PingResponse) EventType() EventType { return EventPingResponse }
+
+func (e *PingResponse) Read(r io.Reader) (err error) {
+	e.Timestamp, err = readUint32Event(r, e.EventType())
+	return err
+}
+
+func (e *PingResponse) Write(w io.Writer) error {
+	return writeUint32Event(w, e.EventType(), e.Timestamp)
+}
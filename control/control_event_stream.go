@@ -0,0 +1,154 @@
+package control
+
+import (
+	"io"
+
+	"github.com/WatchBeam/rtmp/spec"
+)
+
+// readUint32Event reads the body of an event whose only data is a single 32-bit
+// integer, such as a message stream ID.
+func readUint32Event(r io.Reader, typ EventType) (uint32, error) {
+	if err := readEventType(r, typ); err != nil {
+		return 0, err
+	}
+
+	buf, err := spec.ReadBytes(r, 4)
+	if err != nil {
+		return 0, err
+	}
+
+	return spec.Uint32(buf), nil
+}
+
+// writeUint32Event writes the body of an event whose only data is a single
+// 32-bit integer, such as a message stream ID.
+func writeUint32Event(w io.Writer, typ EventType, n uint32) error {
+	if err := writeEventType(w, typ); err != nil {
+		return err
+	}
+
+	_, err := spec.PutUint32(n, w)
+	return err
+}
+
+// StreamBegin notifies the peer that a stream has become functional, and is
+// ready to be used.
+type StreamBegin struct {
+	StreamId uint32
+}
+
+var _ UserEvent = new(StreamBegin)
+
+func (e *StreamBegin) TypeId() byte { return 0x4 }
+
+func (e *StreamBegin) EventType() EventType { return EventStreamBegin }
+
+func (e *StreamBegin) Read(r io.Reader) (err error) {
+	e.StreamId, err = readUint32Event(r, e.EventType())
+	return err
+}
+
+func (e *StreamBegin) Write(w io.Writer) error {
+	return writeUint32Event(w, e.EventType(), e.StreamId)
+}
+
+// StreamEOF notifies the peer that the playback of a stream has ended.
+type StreamEOF struct {
+	StreamId uint32
+}
+
+var _ UserEvent = new(StreamEOF)
+
+func (e *StreamEOF) TypeId() byte { return 0x4 }
+
+func (e *StreamEOF) EventType() EventType { return EventStreamEOF }
+
+func (e *StreamEOF) Read(r io.Reader) (err error) {
+	e.StreamId, err = readUint32Event(r, e.EventType())
+	return err
+}
+
+func (e *StreamEOF) Write(w io.Writer) error {
+	return writeUint32Event(w, e.EventType(), e.StreamId)
+}
+
+// StreamDry notifies the peer that there is no more data on a stream.
+type StreamDry struct {
+	StreamId uint32
+}
+
+var _ UserEvent = new(StreamDry)
+
+func (e *StreamDry) TypeId() byte { return 0x4 }
+
+func (e *StreamDry) EventType() EventType { return EventStreamDry }
+
+func (e *StreamDry) Read(r io.Reader) (err error) {
+	e.StreamId, err = readUint32Event(r, e.EventType())
+	return err
+}
+
+func (e *StreamDry) Write(w io.Writer) error {
+	return writeUint32Event(w, e.EventType(), e.StreamId)
+}
+
+// SetBufferLength informs the server of the length of the buffer used by the
+// client to hold the data of a stream.
+type SetBufferLength struct {
+	StreamId uint32
+	// BufferLength is the length of the buffer, in milliseconds.
+	BufferLength uint32
+}
+
+var _ UserEvent = new(SetBufferLength)
+
+func (e *SetBufferLength) TypeId() byte { return 0x4 }
+
+func (e *SetBufferLength) EventType() EventType { return EventSetBufferLength }
+
+func (e *SetBufferLength) Read(r io.Reader) error {
+	streamId, err := readUint32Event(r, e.EventType())
+	if err != nil {
+		return err
+	}
+
+	buf, err := spec.ReadBytes(r, 4)
+	if err != nil {
+		return err
+	}
+
+	e.StreamId = streamId
+	e.BufferLength = spec.Uint32(buf)
+
+	return nil
+}
+
+func (e *SetBufferLength) Write(w io.Writer) error {
+	if err := writeUint32Event(w, e.EventType(), e.StreamId); err != nil {
+		return err
+	}
+
+	_, err := spec.PutUint32(e.BufferLength, w)
+	return err
+}
+
+// StreamIsRecorded notifies the peer that a stream is a recorded stream.
+type StreamIsRecorded struct {
+	StreamId uint32
+}
+
+var _ UserEvent = new(StreamIsRecorded)
+
+func (e *StreamIsRecorded) TypeId() byte { return 0x4 }
+
+func (e *StreamIsRecorded) EventType() EventType { return EventStreamIsRecorded }
+
+func (e *StreamIsRecorded) Read(r io.Reader) (err error) {
+	e.StreamId, err = readUint32Event(r, e.EventType())
+	return err
+}
+
+func (e *StreamIsRecorded) Write(w io.Writer) error {
+	return writeUint32Event(w, e.EventType(), e.StreamId)
+}
@@ -0,0 +1,77 @@
+package control
+
+import (
+	"io"
+
+	"github.com/WatchBeam/rtmp/spec"
+)
+
+// SWFVerifyRequest is sent by the server to ask the client to prove that it is
+// running an authorized SWF file. It holds no data.
+type SWFVerifyRequest struct{}
+
+var _ UserEvent = new(SWFVerifyRequest)
+
+func (e *SWFVerifyRequest) TypeId() byte { return 0x4 }
+
+func (e *SWFVerifyRequest) EventType() EventType { return EventSWFVerifyRequest }
+
+func (e *SWFVerifyRequest) Read(r io.Reader) error {
+	return readEventType(r, e.EventType())
+}
+
+func (e *SWFVerifyRequest) Write(w io.Writer) error {
+	return writeEventType(w, e.EventType())
+}
+
+// SWFVerifyResponse is sent by the client in response to a SWFVerifyRequest.
+type SWFVerifyResponse struct {
+	// Size is the size of the uncompressed SWF file, in bytes.
+	Size uint32
+	// Hash is the HMAC-SHA256 digest of the uncompressed SWF file, keyed
+	// with the last 32 bytes of the server's handshake.
+	Hash [32]byte
+}
+
+var _ UserEvent = new(SWFVerifyResponse)
+
+func (e *SWFVerifyResponse) TypeId() byte { return 0x4 }
+
+func (e *SWFVerifyResponse) EventType() EventType { return EventSWFVerifyResponse }
+
+// Read reads the body of the response: two bytes, which are always 1, followed
+// by the size of the SWF file (twice), and its hash.
+func (e *SWFVerifyResponse) Read(r io.Reader) error {
+	if err := readEventType(r, e.EventType()); err != nil {
+		return err
+	}
+
+	buf, err := spec.ReadBytes(r, 2+4+4+32)
+	if err != nil {
+		return err
+	}
+
+	e.Size = spec.Uint32(buf[2:6])
+	copy(e.Hash[:], buf[10:])
+
+	return nil
+}
+
+func (e *SWFVerifyResponse) Write(w io.Writer) error {
+	if err := writeEventType(w, e.EventType()); err != nil {
+		return err
+	}
+
+	if _, err := w.Write([]byte{0x01, 0x01}); err != nil {
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := spec.PutUint32(e.Size, w); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write(e.Hash[:])
+	return err
+}
@@ -2,6 +2,7 @@ package control_test
 
 import (
 	"bytes"
+	"reflect"
 	"testing"
 
 	"github.com/WatchBeam/rtmp/control"
@@ -16,14 +17,14 @@ func TestEventReadsFromBuffer(t *testing.T) {
 	}))
 
 	assert.Nil(t, err)
-	assert.Equal(t, control.SetBufferLength, e.Type)
+	assert.Equal(t, control.EventSetBufferLength, e.Type)
 	assert.Equal(t, []byte{1, 2, 3}, e.Body)
 }
 
 func TestEventWritesToBuffer(t *testing.T) {
 	buf := new(bytes.Buffer)
 	e := &control.Event{
-		Type: control.SetBufferLength,
+		Type: control.EventSetBufferLength,
 		Body: []byte{4, 5, 6},
 	}
 
@@ -33,3 +34,39 @@ func TestEventWritesToBuffer(t *testing.T) {
 	assert.Equal(t, []byte{0x00, 0x03}, buf.Bytes()[:2])
 	assert.Equal(t, []byte{0x04, 0x05, 0x06}, buf.Bytes()[2:])
 }
+
+func TestUserEventsRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		Event control.UserEvent
+		Body  []byte
+	}{
+		{&control.StreamBegin{1}, []byte{0, 0, 0, 0, 0, 1}},
+		{&control.StreamEOF{2}, []byte{0, 1, 0, 0, 0, 2}},
+		{&control.StreamDry{3}, []byte{0, 2, 0, 0, 0, 3}},
+		{&control.SetBufferLength{4, 3000},
+			[]byte{0, 3, 0, 0, 0, 4, 0, 0, 0x0b, 0xb8}},
+		{&control.StreamIsRecorded{5}, []byte{0, 4, 0, 0, 0, 5}},
+		{&control.PingRequest{6}, []byte{0, 6, 0, 0, 0, 6}},
+		{&control.PingResponse{7}, []byte{0, 7, 0, 0, 0, 7}},
+		{&control.SWFVerifyRequest{}, []byte{0, 26}},
+		{&control.SWFVerifyResponse{8, [32]byte{9}}, append([]byte{
+			0, 27, 1, 1, 0, 0, 0, 8, 0, 0, 0, 8, 9,
+		}, make([]byte, 31)...)},
+	} {
+		buf := new(bytes.Buffer)
+		assert.Nil(t, test.Event.Write(buf))
+		assert.Equal(t, test.Body, buf.Bytes())
+
+		read := reflect.New(reflect.TypeOf(test.Event).Elem()).Interface()
+		assert.Nil(t, read.(control.UserEvent).Read(buf))
+		assert.Equal(t, test.Event, read)
+	}
+}
+
+func TestUserEventsRejectOtherEventTypes(t *testing.T) {
+	e := new(control.StreamBegin)
+
+	err := e.Read(bytes.NewReader([]byte{0, 1, 0, 0, 0, 1}))
+
+	assert.Equal(t, control.UnexpectedEventType(control.EventStreamEOF), err)
+}
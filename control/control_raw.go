@@ -0,0 +1,39 @@
+package control
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// RawControl holds a control sequence of a type that the DefaultParser does not
+// know about, exactly as it was received. See DefaultParser.SetPassthrough.
+type RawControl struct {
+	// Type is the type ID of the control sequence.
+	Type byte
+	// Body is the body payload of the control sequence.
+	Body []byte
+}
+
+var _ Control = new(RawControl)
+
+// Read implements the Control.Read function by reading the remainder of the
+// given io.Reader into the Body.
+func (c *RawControl) Read(r io.Reader) error {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	c.Body = body
+
+	return nil
+}
+
+// Write implements the Control.Write function by writing the Body as-is.
+func (c *RawControl) Write(w io.Writer) error {
+	_, err := w.Write(c.Body)
+	return err
+}
+
+// TypeId implements the Control.TypeId function, returning the Type.
+func (c *RawControl) TypeId() byte { return c.Type }
@@ -1,11 +1,19 @@
 package control
 
 import (
+	"errors"
 	"io"
 
 	"github.com/WatchBeam/rtmp/spec"
 )
 
+var (
+	// ErrInvalidChunkSize is returned when writing a Set Chunk Size
+	// control sequence that does not hold a chunk size of at least one
+	// byte, which the peer would reject.
+	ErrInvalidChunkSize = errors.New("rtmp/control: invalid chunk size")
+)
+
 type SetChunkSize struct {
 	chunkSize uint32
 }
@@ -32,6 +40,10 @@ func (c *SetChunkSize) Read(r io.Reader) error {
 }
 
 func (c *SetChunkSize) Write(w io.Writer) error {
+	if c.ChunkSize() == 0 {
+		return ErrInvalidChunkSize
+	}
+
 	if _, err := spec.PutUint32(c.ChunkSize(), w); err != nil {
 		return err
 	}
@@ -40,14 +40,13 @@ func (c *DefaultChunker) Chunk(control Control) (*chunk.Chunk, error) {
 
 	return &chunk.Chunk{
 		Header: &chunk.Header{
-			chunk.BasicHeader{0, ControlChunkStreamId},
-			chunk.MessageHeader{
+			BasicHeader: chunk.BasicHeader{0, ControlChunkStreamId},
+			MessageHeader: chunk.MessageHeader{
 				FormatId: 0,
 				Length:   uint32(data.Len()),
 				TypeId:   control.TypeId(),
 				StreamId: ControlMessageStreamId,
 			},
-			chunk.ExtendedTimestamp{},
 		},
 		Data: data.Bytes(),
 	}, nil
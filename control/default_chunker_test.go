@@ -37,3 +37,14 @@ func TestChunkingProducesCorrectChunks(t *testing.T) {
 		0, 0, 0, 5,
 	}, out.Data)
 }
+
+func TestChunkingSerializesTypedEvents(t *testing.T) {
+	c := control.NewChunker()
+
+	out, err := c.Chunk(&control.StreamBegin{1})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 4, out.Header.MessageHeader.TypeId)
+	assert.EqualValues(t, 6, out.Header.MessageHeader.Length)
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 1}, out.Data)
+}
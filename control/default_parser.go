@@ -6,6 +6,7 @@ import (
 	"reflect"
 
 	"github.com/WatchBeam/rtmp/chunk"
+	"github.com/WatchBeam/rtmp/spec"
 )
 
 // UnknownControlType is an Error representing a scenario where an unknown
@@ -20,34 +21,94 @@ func (e UnknownControlType) Error() string {
 }
 
 // DefaultParser provides a default implementation of the Parser type.
+//
+// Each DefaultParser holds its own registry of the control sequences (and User
+// Control Message events) that it knows about, starting out with the ones
+// defined by this package. Additional types may be registered, and built-in
+// ones overridden, with Register and RegisterEvent. Neither those, nor
+// SetPassthrough, are safe to call while Parse may be called from another
+// goroutine.
 type DefaultParser struct {
 	// controls maps control sequence IDs to their respective reflect.Type
 	controls map[byte]reflect.Type
+	// events maps the EventTypes of User Control Messages to the
+	// reflect.Type of their respective UserEvent.
+	events map[EventType]reflect.Type
+	// passthrough is true if control sequences of unknown types are
+	// returned as a *RawControl, rather than an UnknownControlType error.
+	passthrough bool
 }
 
 var _ Parser = new(DefaultParser)
 
 // NewParser returns a new instance of the Parser type (using the DefaultParser
-// implementation) initialized with the Controls variable.
+// implementation) initialized with the Controls and Events variables.
 func NewParser() *DefaultParser {
 	p := &DefaultParser{
 		controls: make(map[byte]reflect.Type),
+		events:   make(map[EventType]reflect.Type),
 	}
 
 	for _, c := range Controls {
-		p.controls[c.TypeId()] = reflect.TypeOf(c).Elem()
+		p.Register(c)
+	}
+
+	for _, e := range Events {
+		p.RegisterEvent(e)
 	}
 
 	return p
 }
 
-// Parse implements the Parse function as defined in the Parser interface.
+// Register registers the type of the given Control with this parser, such that
+// chunks of its TypeId are parsed into a new instance of that type, replacing
+// any type previously registered for the same TypeId. The given Control must
+// be a pointer, such as those in Controls, and is used for nothing but its
+// type.
+func (p *DefaultParser) Register(c Control) {
+	p.controls[c.TypeId()] = reflect.TypeOf(c).Elem()
+}
+
+// RegisterEvent registers the type of the given UserEvent with this parser, such
+// that User Control Messages of its EventType are parsed into a new instance of
+// that type, replacing any type previously registered for the same EventType.
+// The given UserEvent must be a pointer, such as those in Events.
+func (p *DefaultParser) RegisterEvent(e UserEvent) {
+	p.events[e.EventType()] = reflect.TypeOf(e).Elem()
+}
+
+// SetPassthrough determines whether control sequences of types that have not
+// been registered are returned as a *RawControl (if true), or cause Parse to
+// return an UnknownControlType error (if false, which is the default).
+func (p *DefaultParser) SetPassthrough(passthrough bool) {
+	p.passthrough = passthrough
+}
+
+// Parse implements the Parse function as defined in the Parser interface. User
+// Control Messages are returned as the UserEvent matching their EventType, or
+// as an *Event if there is none.
 func (p *DefaultParser) Parse(chunk *chunk.Chunk) (Control, error) {
 	id := chunk.Header.MessageHeader.TypeId
 
 	t := p.TypeFor(id)
 	if t == nil {
-		return nil, UnknownControlType(id)
+		if !p.passthrough {
+			return nil, UnknownControlType(id)
+		}
+
+		raw := &RawControl{Type: id}
+		if err := raw.Read(bytes.NewReader(chunk.Data)); err != nil {
+			return nil, err
+		}
+
+		return raw, nil
+	}
+
+	if id == new(Event).TypeId() && len(chunk.Data) >= 2 {
+		typ := EventType(spec.Uint16(chunk.Data[:2]))
+		if e := p.events[typ]; e != nil {
+			t = e
+		}
 	}
 
 	c := reflect.New(t).Interface().(Control)
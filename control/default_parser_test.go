@@ -1,6 +1,7 @@
 package control_test
 
 import (
+	"io"
 	"math/rand"
 	"reflect"
 	"testing"
@@ -63,3 +64,133 @@ func TestParsingForValidChunks(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, &control.Acknowledgement{n}, ctrl)
 }
+
+func TestParsingReturnsTypedEvents(t *testing.T) {
+	p := control.NewParser()
+
+	ctrl, err := p.Parse(&chunk.Chunk{
+		Header: &chunk.Header{
+			MessageHeader: chunk.MessageHeader{TypeId: 4},
+		},
+		Data: []byte{0, 3, 0, 0, 0, 1, 0, 0, 0, 100},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &control.SetBufferLength{1, 100}, ctrl)
+}
+
+func TestParsingReturnsRawEventsOfUnknownTypes(t *testing.T) {
+	p := control.NewParser()
+
+	ctrl, err := p.Parse(&chunk.Chunk{
+		Header: &chunk.Header{
+			MessageHeader: chunk.MessageHeader{TypeId: 4},
+		},
+		Data: []byte{0, 31, 0, 0, 0, 1},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &control.Event{31, []byte{0, 0, 0, 1}}, ctrl)
+}
+
+// vendorControl is a control sequence type unknown to the control package.
+type vendorControl struct {
+	control.Acknowledgement
+}
+
+func (c *vendorControl) TypeId() byte { return 0x20 }
+
+func TestParsingUnknownTypesReturnsRawControlsWithPassthrough(t *testing.T) {
+	p := control.NewParser()
+	p.SetPassthrough(true)
+
+	ctrl, err := p.Parse(&chunk.Chunk{
+		Header: &chunk.Header{
+			MessageHeader: chunk.MessageHeader{TypeId: 0x20},
+		},
+		Data: []byte{0, 0, 0, 1},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &control.RawControl{0x20, []byte{0, 0, 0, 1}}, ctrl)
+}
+
+func TestParsingRegisteredTypes(t *testing.T) {
+	p := control.NewParser()
+	p.Register(new(vendorControl))
+
+	ctrl, err := p.Parse(&chunk.Chunk{
+		Header: &chunk.Header{
+			MessageHeader: chunk.MessageHeader{TypeId: 0x20},
+		},
+		Data: []byte{0, 0, 0, 1},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &vendorControl{control.Acknowledgement{1}}, ctrl)
+	assert.Nil(t, control.NewParser().TypeFor(0x20))
+}
+
+// rawAcknowledgement overrides the built-in Acknowledgement type.
+type rawAcknowledgement struct {
+	control.RawControl
+}
+
+func (c *rawAcknowledgement) TypeId() byte { return 0x03 }
+
+// vendorEvent is a User Control Message event type unknown to the control
+// package, carrying a single byte.
+type vendorEvent struct {
+	B byte
+}
+
+func (e *vendorEvent) Read(r io.Reader) error {
+	var buf [3]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return err
+	}
+
+	e.B = buf[2]
+
+	return nil
+}
+
+func (e *vendorEvent) Write(w io.Writer) error {
+	_, err := w.Write([]byte{0, 31, e.B})
+	return err
+}
+
+func (e *vendorEvent) TypeId() byte                 { return 0x04 }
+func (e *vendorEvent) EventType() control.EventType { return 31 }
+
+func TestRegisteringOverridesBuiltInTypes(t *testing.T) {
+	p := control.NewParser()
+	p.Register(new(rawAcknowledgement))
+
+	ctrl, err := p.Parse(&chunk.Chunk{
+		Header: &chunk.Header{
+			MessageHeader: chunk.MessageHeader{TypeId: 3},
+		},
+		Data: []byte{0, 0, 0, 1},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &rawAcknowledgement{
+		control.RawControl{Body: []byte{0, 0, 0, 1}},
+	}, ctrl)
+}
+
+func TestParsingRegisteredEvents(t *testing.T) {
+	p := control.NewParser()
+	p.RegisterEvent(new(vendorEvent))
+
+	ctrl, err := p.Parse(&chunk.Chunk{
+		Header: &chunk.Header{
+			MessageHeader: chunk.MessageHeader{TypeId: 4},
+		},
+		Data: []byte{0, 31, 7},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &vendorEvent{7}, ctrl)
+}
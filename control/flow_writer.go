@@ -0,0 +1,258 @@
+package control
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/WatchBeam/rtmp/chunk"
+)
+
+var (
+	// ErrFlowWriterClosed is returned when writing to a FlowWriter that
+	// has been closed, including writes that were blocked at the time of
+	// closing.
+	ErrFlowWriterClosed = errors.New("rtmp/control: flow writer closed")
+)
+
+// FlowPolicy determines what a FlowWriter does with a chunk that cannot be
+// written without exceeding the bandwidth limit set by the peer.
+type FlowPolicy byte
+
+const (
+	// FlowBlock blocks the write until the peer has acknowledged enough
+	// bytes.
+	FlowBlock FlowPolicy = iota
+	// FlowDropMedia discards audio and video messages, and blocks the
+	// write of any other message (as it would be under FlowBlock), since
+	// the session can not survive losing them.
+	FlowDropMedia
+)
+
+// CountingWriter is a chunk.Writer that keeps track of the total number of
+// bytes that it has written, such as *chunk.DefaultWriter.
+type CountingWriter interface {
+	chunk.Writer
+
+	// BytesWritten returns the total number of bytes written, modulo 2^32.
+	BytesWritten() uint32
+}
+
+// FlowWriter is an implementation of the chunk.Writer interface which limits
+// the amount of sent, but unacknowledged, data to the window size set by the
+// peer in a Set Peer Bandwidth control sequence, as described in section
+// 5.4.5 of the RTMP specification.
+//
+// Until the peer sets a bandwidth limit, writes are passed through untouched.
+type FlowWriter struct {
+	// writer is the CountingWriter that chunks are written to.
+	writer CountingWriter
+
+	// mu guards all fields below, and is the Locker of cond.
+	mu sync.Mutex
+	// cond is broadcast to when the limit, acknowledged sequence number,
+	// or the closed state changes.
+	cond *sync.Cond
+	// policy is the policy applied to chunks exceeding the window.
+	policy FlowPolicy
+	// acked is the last sequence number acknowledged by the peer.
+	acked uint32
+	// limit is the number of bytes that may be sent, but unacknowledged.
+	// It is only valid when limited is true.
+	limit uint32
+	// limitType is the type of the limit currently in effect.
+	limitType LimitType
+	// limited is true once the peer has set a bandwidth limit.
+	limited bool
+	// dropped is the number of chunks discarded by the FlowDropMedia
+	// policy.
+	dropped uint64
+	// closed is true once Close has been called.
+	closed bool
+}
+
+var (
+	_ chunk.Writer        = new(FlowWriter)
+	_ chunk.WindowUpdater = new(FlowWriter)
+)
+
+// NewFlowWriter returns a new *FlowWriter writing to the given CountingWriter,
+// and applying the given FlowPolicy.
+func NewFlowWriter(writer CountingWriter, policy FlowPolicy) *FlowWriter {
+	f := &FlowWriter{
+		writer: writer,
+		policy: policy,
+	}
+	f.cond = sync.NewCond(&f.mu)
+
+	return f
+}
+
+// Write implements the chunk.Writer.Write function. If the peer's window is
+// full, the chunk is either blocked until enough bytes have been acknowledged,
+// or dropped, according to the FlowPolicy. Dropped chunks are not considered
+// an error.
+func (f *FlowWriter) Write(c *chunk.Chunk) error {
+	f.mu.Lock()
+	for f.full() && !f.closed {
+		if f.policy == FlowDropMedia && isMedia(c) {
+			f.dropped++
+			f.mu.Unlock()
+
+			return nil
+		}
+
+		f.cond.Wait()
+	}
+	closed := f.closed
+	f.mu.Unlock()
+
+	if closed {
+		return ErrFlowWriterClosed
+	}
+
+	return f.writer.Write(c)
+}
+
+// WriteSize implements the chunk.Writer.WriteSize function.
+func (f *FlowWriter) WriteSize() int { return f.writer.WriteSize() }
+
+// SetWriteSize implements the chunk.Writer.SetWriteSize function.
+func (f *FlowWriter) SetWriteSize(size int) { f.writer.SetWriteSize(size) }
+
+// SetPolicy changes the FlowPolicy applied to chunks exceeding the window.
+func (f *FlowWriter) SetPolicy(policy FlowPolicy) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.policy = policy
+	f.cond.Broadcast()
+}
+
+// Dropped returns the number of chunks that have been discarded by the
+// FlowDropMedia policy.
+func (f *FlowWriter) Dropped() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.dropped
+}
+
+// Acknowledge records the sequence number of an Acknowledgement received from
+// the peer, releasing any writes that are blocked on it.
+func (f *FlowWriter) Acknowledge(seq uint32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.acked = seq
+	f.cond.Broadcast()
+}
+
+// SetPeerBandwidth applies the window size and limit type of a Set Peer
+// Bandwidth control sequence received from the peer:
+//
+//   - Hard limits replace the limit in effect.
+//   - Soft limits replace the limit in effect if they are smaller.
+//   - Dynamic limits are treated as Hard limits if the limit in effect is Hard,
+//     and are ignored otherwise.
+//
+// It returns true if the size of the limit in effect has changed, in which case
+// the peer should be told about the new window with a Window Acknowledgement
+// Size control sequence (see section 5.4.5 of the RTMP specification).
+func (f *FlowWriter) SetPeerBandwidth(size uint32, limitType LimitType) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	limit, limited := f.limit, f.limited
+
+	switch limitType {
+	case LimitTypeHard:
+		f.setLimit(size, LimitTypeHard)
+	case LimitTypeSoft:
+		if !f.limited || size < f.limit {
+			f.setLimit(size, LimitTypeSoft)
+		}
+	case LimitTypeDynamic:
+		if f.limited && f.limitType == LimitTypeHard {
+			f.setLimit(size, LimitTypeHard)
+		}
+	}
+
+	f.cond.Broadcast()
+
+	return f.limited != limited || f.limit != limit
+}
+
+// UpdateWindow implements the chunk.WindowUpdater.UpdateWindow function, so
+// that the FlowWriter may be kept up to date by the chunk.DefaultReader that
+// reads from the peer (see chunk.DefaultReader.SetWindowUpdater). Set Peer
+// Bandwidth control sequences which change the limit in effect are answered
+// with a Window Acknowledgement Size, so that the peer acknowledges according
+// to the new window. Malformed control sequences are ignored.
+func (f *FlowWriter) UpdateWindow(c *chunk.Chunk) error {
+	switch c.TypeId() {
+	case 0x03:
+		ack := new(Acknowledgement)
+		if err := ack.Read(bytes.NewReader(c.Data)); err != nil {
+			return nil
+		}
+
+		f.Acknowledge(ack.SequenceNumber)
+	case 0x06:
+		bw := new(SetPeerBandwidth)
+		if err := bw.Read(bytes.NewReader(c.Data)); err != nil {
+			return nil
+		}
+
+		if !f.SetPeerBandwidth(bw.AckWindowSize, bw.LimitType) {
+			return nil
+		}
+
+		size, err := NewChunker().Chunk(&WindowAckSize{bw.AckWindowSize})
+		if err != nil {
+			return err
+		}
+
+		return f.writer.Write(size)
+	}
+
+	return nil
+}
+
+// Close releases all blocked writes, and causes all future writes to fail with
+// ErrFlowWriterClosed.
+func (f *FlowWriter) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	f.cond.Broadcast()
+}
+
+// setLimit sets the limit in effect. It must be called with mu held.
+func (f *FlowWriter) setLimit(size uint32, limitType LimitType) {
+	f.limit = size
+	f.limitType = limitType
+	f.limited = true
+}
+
+// full returns whether or not the amount of unacknowledged bytes has reached
+// the limit in effect. It must be called with mu held.
+func (f *FlowWriter) full() bool {
+	if !f.limited {
+		return false
+	}
+
+	return f.writer.BytesWritten()-f.acked >= f.limit
+}
+
+// isMedia returns whether or not the given chunk carries an audio or video
+// message.
+func isMedia(c *chunk.Chunk) bool {
+	switch c.TypeId() {
+	case 0x08, 0x09:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,172 @@
+package control_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/rtmp/chunk"
+	"github.com/WatchBeam/rtmp/control"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFlowWriter(policy control.FlowPolicy) (*control.FlowWriter, *bytes.Buffer) {
+	buf := new(bytes.Buffer)
+	w := chunk.NewWriter(buf, chunk.DefaultReadSize).(control.CountingWriter)
+
+	return control.NewFlowWriter(w, policy), buf
+}
+
+func mediaChunk(typeId byte) *chunk.Chunk {
+	return &chunk.Chunk{
+		Header: &chunk.Header{
+			BasicHeader:   chunk.BasicHeader{0, 6},
+			MessageHeader: chunk.MessageHeader{0, 0, false, 8, typeId, 1},
+		},
+		Data: make([]byte, 8),
+	}
+}
+
+func TestFlowWriterPassesThroughWithoutLimit(t *testing.T) {
+	f, buf := newFlowWriter(control.FlowBlock)
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, f.Write(mediaChunk(9)))
+	}
+
+	assert.True(t, buf.Len() > 0)
+}
+
+func TestFlowWriterBlocksUntilAcknowledged(t *testing.T) {
+	f, buf := newFlowWriter(control.FlowBlock)
+	f.SetPeerBandwidth(10, control.LimitTypeHard)
+
+	assert.Nil(t, f.Write(mediaChunk(9)))
+	written := buf.Len()
+
+	done := make(chan error)
+	go func() { done <- f.Write(mediaChunk(9)) }()
+
+	select {
+	case <-done:
+		t.Fatal("control: write should have blocked")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	f.Acknowledge(uint32(written))
+
+	assert.Nil(t, <-done)
+	assert.True(t, buf.Len() > written)
+}
+
+func TestFlowWriterDropsMediaWhenFull(t *testing.T) {
+	f, buf := newFlowWriter(control.FlowDropMedia)
+	f.SetPeerBandwidth(10, control.LimitTypeHard)
+
+	assert.Nil(t, f.Write(mediaChunk(9)))
+	written := buf.Len()
+
+	assert.Nil(t, f.Write(mediaChunk(9)))
+	assert.Nil(t, f.Write(mediaChunk(8)))
+
+	assert.Equal(t, written, buf.Len())
+	assert.Equal(t, uint64(2), f.Dropped())
+}
+
+func TestFlowWriterSoftLimitsOnlyLowerTheLimit(t *testing.T) {
+	f, _ := newFlowWriter(control.FlowDropMedia)
+	f.SetPeerBandwidth(10, control.LimitTypeSoft)
+	f.SetPeerBandwidth(1000, control.LimitTypeSoft)
+
+	f.Write(mediaChunk(9))
+	f.Write(mediaChunk(9))
+
+	assert.Equal(t, uint64(1), f.Dropped())
+}
+
+func TestFlowWriterReportsChangesToTheLimit(t *testing.T) {
+	f, _ := newFlowWriter(control.FlowBlock)
+
+	assert.False(t, f.SetPeerBandwidth(10, control.LimitTypeDynamic))
+	assert.True(t, f.SetPeerBandwidth(10, control.LimitTypeSoft))
+	assert.False(t, f.SetPeerBandwidth(20, control.LimitTypeSoft))
+	assert.False(t, f.SetPeerBandwidth(10, control.LimitTypeHard))
+	assert.True(t, f.SetPeerBandwidth(5, control.LimitTypeDynamic))
+}
+
+func TestFlowWriterDynamicLimitsFollowHardLimits(t *testing.T) {
+	f, _ := newFlowWriter(control.FlowDropMedia)
+	f.SetPeerBandwidth(10, control.LimitTypeDynamic)
+
+	f.Write(mediaChunk(9))
+	f.Write(mediaChunk(9))
+	assert.Equal(t, uint64(0), f.Dropped())
+
+	f.SetPeerBandwidth(1000, control.LimitTypeHard)
+	f.SetPeerBandwidth(10, control.LimitTypeDynamic)
+
+	f.Write(mediaChunk(9))
+	assert.Equal(t, uint64(1), f.Dropped())
+}
+
+func TestFlowWriterClosingReleasesBlockedWrites(t *testing.T) {
+	f, _ := newFlowWriter(control.FlowBlock)
+	f.SetPeerBandwidth(10, control.LimitTypeHard)
+	f.Write(mediaChunk(9))
+
+	done := make(chan error)
+	go func() { done <- f.Write(mediaChunk(9)) }()
+
+	f.Close()
+
+	assert.Equal(t, control.ErrFlowWriterClosed, <-done)
+}
+
+func TestFlowWriterUpdatesItsWindowFromChunks(t *testing.T) {
+	bw, _ := control.NewChunker().Chunk(&control.SetPeerBandwidth{
+		10, control.LimitTypeHard,
+	})
+
+	// The Window Acknowledgement Size answering the limit fills the
+	// window.
+	f, buf := newFlowWriter(control.FlowBlock)
+	assert.Nil(t, f.UpdateWindow(bw))
+	written := buf.Len()
+
+	done := make(chan error)
+	go func() { done <- f.Write(mediaChunk(9)) }()
+
+	select {
+	case <-done:
+		t.Fatal("control: write should have blocked")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	ack, _ := control.NewChunker().Chunk(&control.Acknowledgement{
+		uint32(written),
+	})
+	assert.Nil(t, f.UpdateWindow(ack))
+
+	assert.Nil(t, <-done)
+}
+
+func TestChangedPeerBandwidthsAreAnsweredWithAWindowAckSize(t *testing.T) {
+	hard, _ := control.NewChunker().Chunk(&control.SetPeerBandwidth{
+		10, control.LimitTypeHard,
+	})
+	soft, _ := control.NewChunker().Chunk(&control.SetPeerBandwidth{
+		20, control.LimitTypeSoft,
+	})
+
+	f, buf := newFlowWriter(control.FlowBlock)
+	assert.Nil(t, f.UpdateWindow(hard))
+	assert.Nil(t, f.UpdateWindow(soft))
+
+	// Only the Hard limit changed the window, since the Soft limit is
+	// larger than it.
+	expected := new(bytes.Buffer)
+	c, _ := control.NewChunker().Chunk(&control.WindowAckSize{10})
+	chunk.NewWriter(expected, chunk.DefaultReadSize).Write(c)
+
+	assert.Equal(t, expected.Bytes(), buf.Bytes())
+}
@@ -0,0 +1,100 @@
+package control
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrKeepaliveTimeout is returned by Stream.Err once the peer has left
+	// too many PingRequests unanswered in a row.
+	ErrKeepaliveTimeout = errors.New("rtmp/control: peer missed too many pings")
+)
+
+// keepalive keeps track of the PingRequests sent to the peer by a Stream, and
+// of the round-trip time measured from the PingResponses that answer them.
+type keepalive struct {
+	// interval is the time between two PingRequests.
+	interval time.Duration
+	// maxMissed is the number of PingRequests in a row that the peer may
+	// leave unanswered.
+	maxMissed int
+	// epoch is the time that the timestamps of PingRequests count from.
+	epoch time.Time
+
+	// mu guards all fields below.
+	mu sync.Mutex
+	// pending is true while the last PingRequest has not been answered.
+	pending bool
+	// timestamp is the timestamp of the last PingRequest.
+	timestamp uint32
+	// sent is the time at which the last PingRequest was sent.
+	sent time.Time
+	// missed is the number of PingRequests in a row that went unanswered.
+	missed int
+	// rtt is the smoothed round-trip time, or zero if no PingResponse has
+	// been received yet.
+	rtt time.Duration
+}
+
+// newKeepalive returns a new *keepalive sending PingRequests on the given
+// interval, and giving up after `maxMissed` of them went unanswered.
+func newKeepalive(interval time.Duration, maxMissed int) *keepalive {
+	return &keepalive{
+		interval:  interval,
+		maxMissed: maxMissed,
+		epoch:     time.Now(),
+	}
+}
+
+// ping returns the next PingRequest to send, or ErrKeepaliveTimeout if the
+// last PingRequest was the last one that the peer was allowed to miss.
+func (k *keepalive) ping(now time.Time) (*PingRequest, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.pending {
+		k.missed++
+	}
+
+	if k.maxMissed > 0 && k.missed >= k.maxMissed {
+		return nil, ErrKeepaliveTimeout
+	}
+
+	k.pending = true
+	k.timestamp = uint32(now.Sub(k.epoch) / time.Millisecond)
+	k.sent = now
+
+	return &PingRequest{k.timestamp}, nil
+}
+
+// pong records the given PingResponse, if it answers the last PingRequest. The
+// round-trip time is smoothed the same way as TCP's (see RFC 6298), giving
+// each new sample a weight of 1/8.
+func (k *keepalive) pong(resp *PingResponse, now time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if !k.pending || resp.Timestamp != k.timestamp {
+		return
+	}
+
+	k.pending = false
+	k.missed = 0
+
+	sample := now.Sub(k.sent)
+	if k.rtt == 0 {
+		k.rtt = sample
+	} else {
+		k.rtt += (sample - k.rtt) / 8
+	}
+}
+
+// RTT returns the smoothed round-trip time.
+func (k *keepalive) RTT() time.Duration {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.rtt
+}
@@ -1,6 +1,22 @@
 package control
 
-import "github.com/WatchBeam/rtmp/chunk"
+import (
+	"sync"
+	"time"
+
+	"github.com/WatchBeam/rtmp/chunk"
+)
+
+// Resizer is a chunk.Writer that is able to write a chunk and change its write
+// size without any other chunks being written in between, such as
+// *chunk.DefaultWriter.
+type Resizer interface {
+	chunk.Writer
+
+	// Resize writes the given chunk, and then changes the write size to
+	// `size` before any other chunk may be written.
+	Resize(c *chunk.Chunk, size int) error
+}
 
 // Stream represents an RTMP-compliant bi-directional transfer of RTMP control
 // sequences. It parses control sequences out of a chunk.Stream, and writes them
@@ -13,6 +29,19 @@ type Stream struct {
 	out    chan Control
 	errs   chan error
 	closer chan struct{}
+	// done is closed once Recv has returned.
+	done chan struct{}
+
+	// acks is a channel of sequence numbers to acknowledge to the peer. It
+	// is nil (and thus never ready) unless set by SetAcks.
+	acks <-chan uint32
+	// keepalive pings the peer, if set by SetKeepalive.
+	keepalive *keepalive
+
+	// emu guards err
+	emu sync.Mutex
+	// err is the fatal error which caused Recv to return, if any.
+	err error
 
 	parser  Parser
 	chunker Chunker
@@ -31,6 +60,7 @@ func NewStream(chunks chunk.Stream, writer chunk.Writer,
 		out:    make(chan Control),
 		errs:   make(chan error),
 		closer: make(chan struct{}),
+		done:   make(chan struct{}),
 
 		parser:  parser,
 		chunker: chunker,
@@ -41,51 +71,226 @@ func NewStream(chunks chunk.Stream, writer chunk.Writer,
 func (s *Stream) In() <-chan Control { return s.in }
 
 // Out is written to by callers when they want to write a control sequence to
-// the stream
+// the stream. It must not be written to once Done() has been closed.
 func (s *Stream) Out() chan<- Control { return s.out }
 
+// Done returns a channel which is closed once Recv has returned, either because
+// the Stream was closed, or because the chunk stream that it reads from was
+// closed (such as when the connection has been lost). By then, the In() and
+// Errs() channels have been closed.
+func (s *Stream) Done() <-chan struct{} { return s.done }
+
 // Errs is written to when an error is encountered from the chunk stream, or an
 // error is encountered in chunking or parsing.
 func (s *Stream) Errs() <-chan error { return s.errs }
 
-// Close stops the Recv goroutine.
-func (s *Stream) Close() { s.closer <- struct{}{} }
+// SetAcks sets the channel of sequence numbers that this Stream will
+// automatically send Acknowledgement control sequences for, typically the
+// Acks() channel of a *chunk.Parser. This method is _not_ safe to use between
+// multiple goroutines, and must be called before Recv.
+func (s *Stream) SetAcks(acks <-chan uint32) { s.acks = acks }
+
+// SetKeepalive makes this Stream send a PingRequest to the peer on the given
+// interval, and measure the round-trip time (see RTT) from the PingResponses
+// that answer them. Once `maxMissed` PingRequests in a row went unanswered
+// (where a PingRequest is missed if it has not been answered by the time the
+// next one is due), Recv returns, and Err returns ErrKeepaliveTimeout. A
+// `maxMissed` of zero means that the peer is never given up on. This method is
+// _not_ safe to use between multiple goroutines, and must be called before
+// Recv.
+//
+// PingRequests received from the peer are answered whether or not this method
+// is called.
+func (s *Stream) SetKeepalive(interval time.Duration, maxMissed int) {
+	s.keepalive = newKeepalive(interval, maxMissed)
+}
+
+// RTT returns the smoothed round-trip time to the peer, as measured by the
+// PingRequests sent since SetKeepalive was called. It returns zero until the
+// first PingResponse has been received.
+func (s *Stream) RTT() time.Duration {
+	if s.keepalive == nil {
+		return 0
+	}
+
+	return s.keepalive.RTT()
+}
+
+// Err returns the fatal error which caused Recv to return, such as
+// ErrKeepaliveTimeout, or nil if it has not returned, or returned because the
+// Stream or its chunk stream was closed.
+func (s *Stream) Err() error {
+	s.emu.Lock()
+	defer s.emu.Unlock()
+
+	return s.err
+}
+
+// SetChunkSize tells the peer that chunks will be split according to the given
+// chunk size from now on, by sending a Set Chunk Size control sequence, and then
+// changes the write size of this Stream's chunk.Writer to match. Sending a
+// SetChunkSize control sequence over Out() has the same effect. A size of zero
+// is rejected with ErrInvalidChunkSize before anything is written.
+//
+// Unlike most other methods, SetChunkSize writes directly to the chunk.Writer,
+// and so it may be called whether or not Recv is running.
+func (s *Stream) SetChunkSize(size uint32) error {
+	return s.write(NewSetChunkSize(size))
+}
+
+// Close stops the Recv goroutine. It is safe to call once Recv has already
+// returned.
+func (s *Stream) Close() {
+	select {
+	case s.closer <- struct{}{}:
+	case <-s.done:
+	}
+}
 
 // Recv processes input from all channels, as well as the incoming and outgoing
-// chunk streams.
+// chunk streams. Sequence numbers received over the channel given to SetAcks
+// are acknowledged to the peer, PingRequests are answered, and the peer is
+// pinged if SetKeepalive was called. Recv returns once the Stream is closed,
+// once the chunk stream is, or once the peer missed too many pings.
 //
 // Recv runs within its own goroutine.
 func (s *Stream) Recv() {
 	defer func() {
 		close(s.in)
-		close(s.out)
 		close(s.errs)
-		close(s.closer)
+		close(s.done)
 	}()
 
+	var ticks <-chan time.Time
+	if s.keepalive != nil {
+		ticker := time.NewTicker(s.keepalive.interval)
+		defer ticker.Stop()
+
+		ticks = ticker.C
+	}
+
 	for {
 		select {
 		case <-s.closer:
 			return
-		case c := <-s.chunks.In():
+		case c, ok := <-s.chunks.In():
+			if !ok {
+				return
+			}
+
+			// Control sequences hold copies of everything that
+			// they read from the chunk, which is no longer needed.
 			control, err := s.parser.Parse(c)
+			c.Release()
+
 			if err != nil {
-				s.errs <- err
+				if !s.fail(err) {
+					return
+				}
+
 				continue
 			}
 
-			s.in <- control
+			if err := s.answer(control); err != nil && !s.fail(err) {
+				return
+			}
+
+			if !s.emit(control) {
+				return
+			}
 		case control := <-s.out:
-			chunk, err := s.chunker.Chunk(control)
+			if err := s.write(control); err != nil && !s.fail(err) {
+				return
+			}
+		case seq := <-s.acks:
+			err := s.write(&Acknowledgement{seq})
+			if err != nil && !s.fail(err) {
+				return
+			}
+		case now := <-ticks:
+			ping, err := s.keepalive.ping(now)
 			if err != nil {
-				s.errs <- err
-				continue
+				s.setErr(err)
+				return
 			}
 
-			if err = s.writer.Write(chunk); err != nil {
-				s.errs <- err
-				continue
+			if err := s.write(ping); err != nil && !s.fail(err) {
+				return
 			}
 		}
 	}
 }
+
+// setErr records the fatal error which caused Recv to return.
+func (s *Stream) setErr(err error) {
+	s.emu.Lock()
+	defer s.emu.Unlock()
+
+	s.err = err
+}
+
+// emit pushes the given control sequence over the in channel, returning false
+// if the Stream was closed while waiting for it to be read.
+func (s *Stream) emit(control Control) bool {
+	select {
+	case s.in <- control:
+		return true
+	case <-s.closer:
+		return false
+	}
+}
+
+// fail pushes the given error over the errs channel, returning false if the
+// Stream was closed while waiting for it to be read.
+func (s *Stream) fail(err error) bool {
+	select {
+	case s.errs <- err:
+		return true
+	case <-s.closer:
+		return false
+	}
+}
+
+// answer replies to incoming PingRequests, and records incoming PingResponses
+// with the keepalive (if there is one).
+func (s *Stream) answer(control Control) error {
+	switch c := control.(type) {
+	case *PingRequest:
+		return s.write(&PingResponse{c.Timestamp})
+	case *PingResponse:
+		if s.keepalive != nil {
+			s.keepalive.pong(c, time.Now())
+		}
+	}
+
+	return nil
+}
+
+// write chunks the given control sequence and writes it to the chunk.Writer,
+// returning any error encountered along the way. Once a Set Chunk Size control
+// sequence has been written, the write size of the chunk.Writer is changed to
+// match it. If the chunk.Writer is a Resizer, this happens before any other
+// chunk is written.
+func (s *Stream) write(control Control) error {
+	chunk, err := s.chunker.Chunk(control)
+	if err != nil {
+		return err
+	}
+
+	size, ok := control.(*SetChunkSize)
+	if !ok {
+		return s.writer.Write(chunk)
+	}
+
+	if r, ok := s.writer.(Resizer); ok {
+		return r.Resize(chunk, int(size.ChunkSize()))
+	}
+
+	if err := s.writer.Write(chunk); err != nil {
+		return err
+	}
+
+	s.writer.SetWriteSize(int(size.ChunkSize()))
+
+	return nil
+}
@@ -3,8 +3,10 @@ package control_test
 import (
 	"bytes"
 	"errors"
+	"io"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/WatchBeam/rtmp/chunk"
 	"github.com/WatchBeam/rtmp/control"
@@ -35,9 +37,25 @@ func newStreamWithChunk(streamId uint32, chunks ...*chunk.Chunk) chunk.Stream {
 	go parser.Recv()
 
 	st, _ := parser.Stream(streamId)
-	return st
+
+	// The parser closes its chunk streams once everything has been read,
+	// which would stop the control.Stream, so the chunks are handed over
+	// through a stream that stays open.
+	open := make(openStream)
+	go func() {
+		for c := range st.In() {
+			open <- c
+		}
+	}()
+
+	return open
 }
 
+// openStream is a chunk.Stream that is never closed.
+type openStream chan *chunk.Chunk
+
+func (s openStream) In() <-chan *chunk.Chunk { return s }
+
 func TestStreamConstruction(t *testing.T) {
 	s := control.NewStream(nil, nil, nil, nil)
 
@@ -109,3 +127,148 @@ func TestWritingAControlErrorsWhenErrored(t *testing.T) {
 	assert.Equal(t, "test", (<-stream.Errs()).Error())
 	chunker.AssertExpectations(t)
 }
+
+func TestAcksAreWrittenAsAcknowledgements(t *testing.T) {
+	buf := new(bytes.Buffer)
+	acks := make(chan uint32)
+
+	stream := control.NewStream(
+		newStreamWithChunk(2), chunk.NewWriter(buf, chunk.DefaultReadSize),
+		nil, control.NewChunker(),
+	)
+	stream.SetAcks(acks)
+	go stream.Recv()
+
+	acks <- 1234
+	stream.Close()
+
+	expected := new(bytes.Buffer)
+	c, _ := control.NewChunker().Chunk(&control.Acknowledgement{1234})
+	chunk.NewWriter(expected, chunk.DefaultReadSize).Write(c)
+
+	assert.Equal(t, expected.Bytes(), buf.Bytes())
+}
+
+func TestSetChunkSizeAnnouncesAndChangesTheWriteSize(t *testing.T) {
+	buf := new(bytes.Buffer)
+	writer := chunk.NewWriter(buf, chunk.DefaultReadSize)
+
+	stream := control.NewStream(nil, writer, nil, control.NewChunker())
+
+	assert.Nil(t, stream.SetChunkSize(4096))
+	assert.Equal(t, 4096, writer.WriteSize())
+
+	expected := new(bytes.Buffer)
+	c, _ := control.NewChunker().Chunk(control.NewSetChunkSize(4096))
+	chunk.NewWriter(expected, chunk.DefaultReadSize).Write(c)
+
+	assert.Equal(t, expected.Bytes(), buf.Bytes())
+}
+
+func TestSetChunkSizeRejectsZero(t *testing.T) {
+	buf := new(bytes.Buffer)
+	writer := chunk.NewWriter(buf, chunk.DefaultReadSize)
+
+	stream := control.NewStream(nil, writer, nil, control.NewChunker())
+
+	assert.Equal(t, control.ErrInvalidChunkSize, stream.SetChunkSize(0))
+	assert.Equal(t, chunk.DefaultReadSize, writer.WriteSize())
+	assert.Equal(t, 0, buf.Len())
+}
+
+func TestSetChunkSizeSentOverOutChangesTheWriteSize(t *testing.T) {
+	writer := chunk.NewWriter(ioutil.Discard, chunk.DefaultReadSize)
+
+	stream := control.NewStream(
+		newStreamWithChunk(2), writer, nil, control.NewChunker(),
+	)
+	go stream.Recv()
+
+	stream.Out() <- control.NewSetChunkSize(4096)
+	stream.Close()
+
+	assert.Equal(t, 4096, writer.WriteSize())
+}
+
+func TestStreamStopsWhenTheChunkStreamCloses(t *testing.T) {
+	chunks := make(openStream)
+
+	stream := control.NewStream(chunks, nil, nil, nil)
+	go stream.Recv()
+
+	close(chunks)
+	<-stream.Done()
+
+	_, open := <-stream.In()
+	assert.False(t, open)
+	_, open = <-stream.Errs()
+	assert.False(t, open)
+
+	stream.Close()
+}
+
+func TestPingRequestsAreAnswered(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ping, _ := control.NewChunker().Chunk(&control.PingRequest{42})
+
+	stream := control.NewStream(
+		newStreamWithChunk(2, ping),
+		chunk.NewWriter(buf, chunk.DefaultReadSize),
+		control.NewParser(), control.NewChunker(),
+	)
+	go stream.Recv()
+
+	assert.Equal(t, &control.PingRequest{42}, <-stream.In())
+	stream.Close()
+
+	expected := new(bytes.Buffer)
+	c, _ := control.NewChunker().Chunk(&control.PingResponse{42})
+	chunk.NewWriter(expected, chunk.DefaultReadSize).Write(c)
+
+	assert.Equal(t, expected.Bytes(), buf.Bytes())
+}
+
+func TestKeepaliveMeasuresTheRoundTripTime(t *testing.T) {
+	pr, pw := io.Pipe()
+	chunks := make(openStream)
+
+	stream := control.NewStream(
+		chunks, chunk.NewWriter(pw, chunk.DefaultReadSize),
+		control.NewParser(), control.NewChunker(),
+	)
+	// The interval leaves enough time to answer the first PingRequest
+	// before the next one replaces it.
+	stream.SetKeepalive(50*time.Millisecond, 0)
+	go stream.Recv()
+
+	r := chunk.NewReader(pr, chunk.DefaultReadSize, chunk.NoopNormalizer)
+	c, err := r.(*chunk.DefaultReader).ReadMessage()
+	assert.Nil(t, err)
+	go io.Copy(ioutil.Discard, pr)
+
+	ctrl, _ := control.NewParser().Parse(c)
+	ping := ctrl.(*control.PingRequest)
+
+	time.Sleep(time.Millisecond)
+	pong, _ := control.NewChunker().Chunk(&control.PingResponse{ping.Timestamp})
+	chunks <- pong
+	<-stream.In()
+
+	assert.True(t, stream.RTT() >= time.Millisecond)
+	stream.Close()
+}
+
+func TestKeepaliveGivesUpAfterMissedPings(t *testing.T) {
+	stream := control.NewStream(
+		newStreamWithChunk(2),
+		chunk.NewWriter(ioutil.Discard, chunk.DefaultReadSize),
+		control.NewParser(), control.NewChunker(),
+	)
+	stream.SetKeepalive(time.Millisecond, 2)
+	go stream.Recv()
+
+	<-stream.Done()
+
+	assert.Equal(t, control.ErrKeepaliveTimeout, stream.Err())
+	assert.Equal(t, time.Duration(0), stream.RTT())
+}
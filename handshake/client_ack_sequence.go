@@ -12,6 +12,9 @@ import (
 type ClientAckSequence struct {
 	C1 *AckPacket
 	S1 *AckPacket
+
+	// read is true once C1 has been read.
+	read bool
 }
 
 var _ Sequence = new(ClientAckSequence)
@@ -37,6 +40,7 @@ func (c *ClientAckSequence) Read(r io.Reader) error {
 	if err := c.C1.Read(r); err != nil {
 		return err
 	}
+	c.read = true
 
 	return nil
 }
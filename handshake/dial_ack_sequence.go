@@ -0,0 +1,71 @@
+package handshake
+
+import (
+	"bytes"
+	"io"
+)
+
+// DialAckSequence is the last sequence of the simple handshake when playing
+// the role of the client. It is responsible for reading the S0, S1 and S2
+// packets, verifying that S2 acknowledges C1, and acknowledging S1 with the C2
+// packet.
+type DialAckSequence struct {
+	// Supported is the version byte that S0 must carry.
+	Supported byte
+	// C1 is the packet which S2 should acknowledge.
+	C1 *AckPacket
+	// S1 is the packet read from the server, which C2 acknowledges.
+	S1 *AckPacket
+}
+
+var _ Sequence = new(DialAckSequence)
+
+// NewDialAckSequence returns a new *DialAckSequence expecting the given version
+// from the server, and initialized with the given C1 packet.
+func NewDialAckSequence(supported byte, C1 *AckPacket) *DialAckSequence {
+	return &DialAckSequence{
+		Supported: supported,
+		C1:        C1,
+		S1:        new(AckPacket),
+	}
+}
+
+// Read implements the Sequence.Read function by reading the S0, S1 and S2
+// packets. If a read error occured, or S0 does not carry the expected version,
+// then it will be returned. If the payload of S2 does not match that of C1,
+// then MismatchedChallengeErr will be returned.
+func (s *DialAckSequence) Read(r io.Reader) error {
+	if err := (&VerisonSequence{Supported: s.Supported}).Read(r); err != nil {
+		return err
+	}
+
+	if err := s.S1.Read(r); err != nil {
+		return err
+	}
+
+	s2 := new(AckPacket)
+	if err := s2.Read(r); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(s.C1.Payload[:], s2.Payload[:]) {
+		return MismatchedChallengeErr
+	}
+
+	return nil
+}
+
+// WriteTo implements the Sequence.WriteTo function by writing the C2 packet,
+// with the same data as was sent in the S1 packet.
+func (s *DialAckSequence) WriteTo(w io.Writer) error {
+	c2 := &AckPacket{
+		Time1:   s.S1.Time1,
+		Payload: s.S1.Payload,
+	}
+
+	return c2.Write(w)
+}
+
+// Next implements the Sequence.Next function. Since there is no next function,
+// this function always returns nil.
+func (s *DialAckSequence) Next() Sequence { return nil }
@@ -0,0 +1,42 @@
+package handshake_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/WatchBeam/rtmp/handshake"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialAckSequenceReportsMismatchedChallenges(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(handshake.SupportedRTMPVersion)         // S0
+	(&handshake.AckPacket{Payload: payload()}).Write(buf) // S1
+	(&handshake.AckPacket{Payload: payload()}).Write(buf) // S2
+
+	s := handshake.NewDialAckSequence(
+		handshake.SupportedRTMPVersion,
+		&handshake.AckPacket{Payload: payload()},
+	)
+
+	assert.Equal(t, handshake.MismatchedChallengeErr, s.Read(buf))
+}
+
+func TestDialAckSequenceEchoesS1(t *testing.T) {
+	c1 := &handshake.AckPacket{Payload: payload()}
+	s1 := &handshake.AckPacket{Time1: 1, Payload: payload()}
+
+	in := new(bytes.Buffer)
+	in.WriteByte(handshake.SupportedRTMPVersion)
+	s1.Write(in)
+	c1.Write(in)
+
+	s := handshake.NewDialAckSequence(handshake.SupportedRTMPVersion, c1)
+	out := new(bytes.Buffer)
+
+	assert.Nil(t, s.Read(in))
+	assert.Nil(t, s.WriteTo(out))
+	assert.Equal(t, []byte{0, 0, 0, 1}, out.Bytes()[:4])
+	assert.Equal(t, s1.Payload[:], out.Bytes()[8:])
+	assert.Nil(t, s.Next())
+}
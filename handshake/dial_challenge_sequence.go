@@ -0,0 +1,58 @@
+package handshake
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+)
+
+// DialChallengeSequence is a handshake sequence played by the client during the
+// simple handshake. It is responsible for sending the version in C0, together
+// with the client challenge in C1.
+type DialChallengeSequence struct {
+	// Supported is the version byte sent in C0, which S0 must carry.
+	Supported byte
+
+	C1 *AckPacket
+}
+
+var _ Sequence = new(DialChallengeSequence)
+
+// NewDialChallengeSequence initializes and returns a new
+// *DialChallengeSequence, sending the given version to the server, and
+// initialized with a new C1 packet, initialized with the result of a
+// "rand.Read" into its Payload header.
+func NewDialChallengeSequence(supported byte) *DialChallengeSequence {
+	c := &DialChallengeSequence{
+		Supported: supported,
+		C1:        new(AckPacket),
+	}
+	rand.Read(c.C1.Payload[:])
+
+	return c
+}
+
+// Read implements the Sequence.Read function. Since the server waits for C0 and
+// C1 before answering, there is nothing to read, and a value of nil is always
+// returned here.
+func (c *DialChallengeSequence) Read(r io.Reader) error { return nil }
+
+// WriteTo implements the Sequence.WriteTo function by writing the C0 and C1
+// packets with a single write.
+func (c *DialChallengeSequence) WriteTo(w io.Writer) error {
+	buf := bytes.NewBuffer([]byte{c.Supported})
+	if err := c.C1.Write(buf); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Next implements the Sequence.Next function.
+func (c *DialChallengeSequence) Next() Sequence {
+	return NewDialAckSequence(c.Supported, c.C1)
+}
@@ -0,0 +1,64 @@
+package handshake
+
+import "io"
+
+// DialVersionSequence is the first sequence of the handshake when playing the
+// role of the client. It is responsible for choosing the RTMP version sent in
+// C0, and the handshake that is performed. Since servers wait for both C0 and
+// C1 before answering, C0 is sent together with C1 by the next sequence.
+type DialVersionSequence struct {
+	// Supported is the version byte sent to the server.
+	Supported byte
+	// Digest is true if the digest handshake is performed, rather than the
+	// simple handshake. See DigestDialChallengeSequence.
+	Digest bool
+	// Strict is true if the server must perform the digest handshake, too.
+	Strict bool
+	// Encrypted is true if the connection is encrypted (RTMPE), in which
+	// case the EncryptedRTMPVersion is sent instead of the Supported
+	// version, and the digest handshake is performed.
+	Encrypted bool
+}
+
+var _ Sequence = new(DialVersionSequence)
+
+// NewDialVersionSequence instantiates and returns a pointer to a new instance
+// of the DialVersionSequence type, which performs the digest handshake if
+// digest is true, and requires the server to do so if strict is true.
+func NewDialVersionSequence(digest, strict bool) *DialVersionSequence {
+	return &DialVersionSequence{
+		Supported: SupportedRTMPVersion,
+		Digest:    digest,
+		Strict:    strict,
+	}
+}
+
+// Read implements the Sequence.Read function. Since the client speaks first,
+// there is nothing to read, and a value of nil is always returned here.
+func (v *DialVersionSequence) Read(r io.Reader) error { return nil }
+
+// WriteTo implements the Sequence.WriteTo function. Since C0 is written by the
+// next sequence, a value of nil is always returned here.
+func (v *DialVersionSequence) WriteTo(w io.Writer) error { return nil }
+
+// Next returns the DigestDialChallengeSequence if the digest handshake is
+// performed, or the DialChallengeSequence otherwise.
+func (v *DialVersionSequence) Next() Sequence {
+	if v.Digest || v.Encrypted {
+		c := NewDigestDialChallengeSequence(v.version(), v.Strict)
+		c.Encrypted = v.Encrypted
+
+		return c
+	}
+
+	return NewDialChallengeSequence(v.Supported)
+}
+
+// version returns the version byte sent to the server.
+func (v *DialVersionSequence) version() byte {
+	if v.Encrypted {
+		return EncryptedRTMPVersion
+	}
+
+	return v.Supported
+}
@@ -0,0 +1,104 @@
+package handshake
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+)
+
+const (
+	// DigestLen is the length of the HMAC-SHA256 digests exchanged during
+	// the digest handshake.
+	DigestLen int = sha256.Size
+	// PacketLen is the length of an encoded AckPacket.
+	PacketLen int = 4 + 4 + PayloadLen
+
+	// serverVersion is the version sent in the Time2 field of S1 during the
+	// digest handshake.
+	serverVersion uint32 = 0x0d0e0a0d
+)
+
+var (
+	// InvalidDigestErr is an error which is returned in strict mode when a
+	// packet sent by the client does not carry a valid digest.
+	InvalidDigestErr = errors.New("rtmp/handshake: invalid digest")
+
+	// keySuffix is the random part shared by the Flash Player and Flash
+	// Media Server keys.
+	keySuffix = []byte{
+		0xf0, 0xee, 0xc2, 0x4a, 0x80, 0x68, 0xbe, 0xe8,
+		0x2e, 0x00, 0xd0, 0xd1, 0x02, 0x9e, 0x7e, 0x57,
+		0x6e, 0xec, 0x5d, 0x2d, 0x29, 0x80, 0x6f, 0xab,
+		0x93, 0xb8, 0xe6, 0x36, 0xcf, 0xeb, 0x31, 0xae,
+	}
+
+	// fpKey is the key that packets sent by the client are digested with.
+	// Only its first 30 bytes are used to digest C1.
+	fpKey = append([]byte("Genuine Adobe Flash Player 001"), keySuffix...)
+	// fmsKey is the key that packets sent by the server are digested
+	// with. Only its first 36 bytes are used to digest S1.
+	fmsKey = append([]byte("Genuine Adobe Flash Media Server 001"), keySuffix...)
+)
+
+// digestOffset returns the offset of the digest within the given encoded
+// packet, according to the given schema: either 0, where the digest is found
+// in the first half of the payload, or 1, where it is found in the second.
+func digestOffset(packet []byte, schema int) int {
+	base := 8 + schema*764
+	sum := int(packet[base]) + int(packet[base+1]) +
+		int(packet[base+2]) + int(packet[base+3])
+
+	return sum%728 + base + 4
+}
+
+// digestAt returns the HMAC-SHA256 digest of the given encoded packet, keyed
+// with the given key, leaving out the DigestLen bytes at the given offset.
+func digestAt(packet []byte, offset int, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(packet[:offset])
+	mac.Write(packet[offset+DigestLen:])
+
+	return mac.Sum(nil)
+}
+
+// findDigest returns the digest carried by the given encoded packet and the
+// schema that it was found with, trying schema 0 first, or nil if the packet
+// carries no digest valid for the given key.
+func findDigest(packet []byte, key []byte) ([]byte, int) {
+	for schema := 0; schema < 2; schema++ {
+		offset := digestOffset(packet, schema)
+		digest := packet[offset : offset+DigestLen]
+
+		if hmac.Equal(digest, digestAt(packet, offset, key)) {
+			return digest, schema
+		}
+	}
+
+	return nil, 0
+}
+
+// responseDigest returns the digest carried in the last DigestLen bytes of a
+// packet sent in response to the given digest, keyed with the given key.
+func responseDigest(packet []byte, digest []byte, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(digest)
+
+	return digestAt(packet, PacketLen-DigestLen, mac.Sum(nil))
+}
+
+// encode returns the encoded form of the given AckPacket.
+func encode(a *AckPacket) []byte {
+	buf := bytes.NewBuffer(make([]byte, 0, PacketLen))
+	a.Write(buf)
+
+	return buf.Bytes()
+}
+
+// decode returns the AckPacket encoded in the given packet.
+func decode(packet []byte) *AckPacket {
+	a := new(AckPacket)
+	a.Read(bytes.NewReader(packet))
+
+	return a
+}
@@ -0,0 +1,174 @@
+package handshake
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+)
+
+// DigestClientAckSequence is a handshake sequence that performs the digest (or
+// "complex") handshake introduced by Flash Player 9, falling back to the simple
+// handshake performed by ClientAckSequence for clients which do not support
+// it. It is responsible for reading and verifying the digest in the C1 packet,
+// and responding with a digested S1 packet and a matching S2 packet.
+//
+// If the client asked for the connection to be encrypted (RTMPE), C1 and S1
+// carry the Diffie-Hellman public keys that the RC4 keys encrypting the
+// connection are derived from, and the DigestClientAckSequence is the
+// Transport which encrypts it.
+type DigestClientAckSequence struct {
+	// Strict is true if C1 (and later, C2) must carry a valid digest,
+	// rather than falling back to the simple handshake.
+	Strict bool
+	// Encrypted is true if the connection is encrypted, in which case C1
+	// must carry a valid digest, too.
+	Encrypted bool
+
+	C1 *AckPacket
+	S1 *AckPacket
+
+	// read is true once C1 has been read.
+	read bool
+
+	// digest is the digest carried by C1, or nil if it carried none, in
+	// which case the simple handshake is performed.
+	digest []byte
+	// schema is the schema that the digest was found with, which S1 is
+	// digested with, too.
+	schema int
+	// s1Digest is the digest carried by S1, if any.
+	s1Digest []byte
+
+	// peerKey is the public key carried by C1, and key is the key pair
+	// whose public key is carried by S1, if the connection is encrypted.
+	peerKey []byte
+	key     *dhKey
+	// secret is the secret shared with the client, if the connection is
+	// encrypted.
+	secret []byte
+}
+
+var (
+	_ Sequence  = new(DigestClientAckSequence)
+	_ Transport = new(DigestClientAckSequence)
+)
+
+// NewDigestClientAckSequence initializes and returns a new
+// *DigestClientAckSequence, which rejects clients that do not perform the
+// digest handshake if strict is true.
+func NewDigestClientAckSequence(strict bool) *DigestClientAckSequence {
+	return &DigestClientAckSequence{
+		Strict: strict,
+		C1:     new(AckPacket),
+	}
+}
+
+// Read implements the Sequence.Read function. It reads the C1 packet, and looks
+// for its digest, unless its Time2 field (the client version) is zero, as it
+// is in the simple handshake. If no valid digest is found, InvalidDigestErr is
+// returned in strict mode, or if the connection is encrypted.
+func (c *DigestClientAckSequence) Read(r io.Reader) error {
+	if err := c.C1.Read(r); err != nil {
+		return err
+	}
+	c.read = true
+
+	c1 := encode(c.C1)
+	if c.C1.Time2 != 0 {
+		c.digest, c.schema = findDigest(c1, fpKey[:30])
+	}
+
+	if c.digest == nil && (c.Strict || c.Encrypted) {
+		return InvalidDigestErr
+	}
+
+	if c.Encrypted {
+		offset := dhOffset(c1, c.schema)
+		c.peerKey = c1[offset : offset+KeyLen]
+	}
+
+	return nil
+}
+
+// WriteTo implements the Sequence.WriteTo function. If C1 carried a digest, it
+// writes an S1 packet digested with the same schema, and an S2 packet digested
+// in response to C1. Otherwise, it writes a random S1 packet and an S2 packet
+// echoing C1, as in the simple handshake.
+func (c *DigestClientAckSequence) WriteTo(w io.Writer) error {
+	if c.digest == nil {
+		simple := NewClientAckSequence()
+		simple.C1 = c.C1
+		c.S1 = simple.S1
+
+		return simple.WriteTo(w)
+	}
+
+	s1 := make([]byte, PacketLen)
+	rand.Read(s1[8:])
+	binary.BigEndian.PutUint32(s1[4:], serverVersion)
+
+	if c.Encrypted {
+		if err := c.exchange(s1); err != nil {
+			return err
+		}
+	}
+
+	offset := digestOffset(s1, c.schema)
+	c.s1Digest = digestAt(s1, offset, fmsKey[:36])
+	copy(s1[offset:], c.s1Digest)
+	c.S1 = decode(s1)
+
+	s2 := make([]byte, PacketLen)
+	rand.Read(s2)
+	copy(s2[PacketLen-DigestLen:], responseDigest(s2, c.digest, fmsKey))
+
+	if _, err := w.Write(s1); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(s2); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Next implements the Sequence.Next function, returning the
+// DigestServerAckSequence if C1 carried a digest, or the ServerAckSequence
+// otherwise.
+func (c *DigestClientAckSequence) Next() Sequence {
+	if c.digest == nil {
+		return NewServerAckSequence(c.S1)
+	}
+
+	return NewDigestServerAckSequence(c.s1Digest, c.Strict)
+}
+
+// exchange generates the key pair whose public key is carried by the given S1
+// packet, and computes the secret shared with the client.
+func (c *DigestClientAckSequence) exchange(s1 []byte) error {
+	key, err := newDHKey()
+	if err != nil {
+		return err
+	}
+
+	secret, err := key.Secret(c.peerKey)
+	if err != nil {
+		return err
+	}
+
+	copy(s1[dhOffset(s1, c.schema):], key.Public)
+	c.key, c.secret = key, secret
+
+	return nil
+}
+
+// Wrap implements the Transport.Wrap function, encrypting the given connection
+// if the client asked for it to be encrypted.
+func (c *DigestClientAckSequence) Wrap(rw io.ReadWriter) io.ReadWriter {
+	if !c.Encrypted {
+		return rw
+	}
+
+	return encrypt(rw, c.secret, c.key.Public, c.peerKey)
+}
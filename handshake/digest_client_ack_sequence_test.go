@@ -0,0 +1,111 @@
+package handshake_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/WatchBeam/rtmp/handshake"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	KeySuffix = []byte{
+		0xf0, 0xee, 0xc2, 0x4a, 0x80, 0x68, 0xbe, 0xe8,
+		0x2e, 0x00, 0xd0, 0xd1, 0x02, 0x9e, 0x7e, 0x57,
+		0x6e, 0xec, 0x5d, 0x2d, 0x29, 0x80, 0x6f, 0xab,
+		0x93, 0xb8, 0xe6, 0x36, 0xcf, 0xeb, 0x31, 0xae,
+	}
+
+	FPKey  = append([]byte("Genuine Adobe Flash Player 001"), KeySuffix...)
+	FMSKey = append([]byte("Genuine Adobe Flash Media Server 001"), KeySuffix...)
+)
+
+// DigestOffset returns the offset of the digest in the given packet, according
+// to the given schema.
+func DigestOffset(packet []byte, schema int) int {
+	base := 8 + schema*764
+	sum := int(packet[base]) + int(packet[base+1]) +
+		int(packet[base+2]) + int(packet[base+3])
+
+	return sum%728 + base + 4
+}
+
+// HMAC returns the HMAC-SHA256 of the given parts, keyed with the given key.
+func HMAC(key []byte, parts ...[]byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	for _, part := range parts {
+		mac.Write(part)
+	}
+
+	return mac.Sum(nil)
+}
+
+// DigestedC1 returns a C1 packet digested according to the given schema, and
+// its digest.
+func DigestedC1(schema int) ([]byte, []byte) {
+	c1 := make([]byte, handshake.PacketLen)
+	rand.Read(c1[8:])
+	copy(c1[4:], []byte{0x80, 0x00, 0x07, 0x02})
+
+	offset := DigestOffset(c1, schema)
+	digest := HMAC(FPKey[:30], c1[:offset], c1[offset+handshake.DigestLen:])
+	copy(c1[offset:], digest)
+
+	return c1, digest
+}
+
+func TestDigestClientAckSequenceRespondsToDigestedC1(t *testing.T) {
+	for schema := 0; schema < 2; schema++ {
+		c1, digest := DigestedC1(schema)
+
+		c := handshake.NewDigestClientAckSequence(true)
+		buf := new(bytes.Buffer)
+
+		assert.Nil(t, c.Read(bytes.NewReader(c1)))
+		assert.Nil(t, c.WriteTo(buf))
+		assert.Len(t, buf.Bytes(), 2*handshake.PacketLen)
+
+		s1 := buf.Bytes()[:handshake.PacketLen]
+		offset := DigestOffset(s1, schema)
+		s1Digest := s1[offset : offset+handshake.DigestLen]
+		assert.Equal(t, HMAC(FMSKey[:36], s1[:offset],
+			s1[offset+handshake.DigestLen:]), s1Digest)
+
+		s2 := buf.Bytes()[handshake.PacketLen:]
+		end := handshake.PacketLen - handshake.DigestLen
+		assert.Equal(t, HMAC(HMAC(FMSKey, digest), s2[:end]), s2[end:])
+
+		assert.Equal(t, &handshake.DigestServerAckSequence{
+			Digest: s1Digest,
+			Strict: true,
+		}, c.Next())
+	}
+}
+
+func TestDigestClientAckSequenceFallsBackToTheSimpleHandshake(t *testing.T) {
+	c1 := &handshake.AckPacket{Payload: payload()}
+	in := new(bytes.Buffer)
+	c1.Write(in)
+
+	c := handshake.NewDigestClientAckSequence(false)
+	buf := new(bytes.Buffer)
+
+	assert.Nil(t, c.Read(in))
+	assert.Nil(t, c.WriteTo(buf))
+
+	start := handshake.PacketLen + 8
+	assert.Equal(t, c1.Payload[:], buf.Bytes()[start:])
+	assert.Equal(t, handshake.NewServerAckSequence(c.S1), c.Next())
+}
+
+func TestDigestClientAckSequenceRejectsUndigestedC1WhenStrict(t *testing.T) {
+	c1, _ := DigestedC1(0)
+	c1[handshake.PacketLen-1] ^= 0xff
+
+	c := handshake.NewDigestClientAckSequence(true)
+
+	assert.Equal(t, handshake.InvalidDigestErr, c.Read(bytes.NewReader(c1)))
+}
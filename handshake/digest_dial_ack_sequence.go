@@ -0,0 +1,151 @@
+package handshake
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"io"
+)
+
+// DigestDialAckSequence is the last sequence of the digest handshake when
+// playing the role of the client. It is responsible for reading the S0, S1 and
+// S2 packets, and acknowledging the digest carried by S1 with a digested C2
+// packet. If S1 carries no digest, the server is assumed to perform the simple
+// handshake, and C2 echoes S1, as it does in the DialAckSequence.
+//
+// If the connection is encrypted (RTMPE), S1 carries the server's
+// Diffie-Hellman public key, and the DigestDialAckSequence is the Transport
+// which encrypts the connection.
+type DigestDialAckSequence struct {
+	// Supported is the version byte that S0 must carry.
+	Supported byte
+	// C1 is the packet which S2 should acknowledge.
+	C1 *AckPacket
+	// Digest is the digest carried by C1.
+	Digest []byte
+	// Strict is true if the server must perform the digest handshake,
+	// sending a digested S1, and an S2 digested in response to C1.
+	Strict bool
+
+	// S1 is the packet read from the server, which C2 acknowledges.
+	S1 *AckPacket
+
+	// s1Digest is the digest carried by S1, or nil if it carried none.
+	s1Digest []byte
+
+	// key is the key pair whose public key is carried by C1, if the
+	// connection is encrypted.
+	key *dhKey
+	// peerKey is the public key carried by S1, and secret is the secret
+	// shared with the server, if the connection is encrypted.
+	peerKey []byte
+	secret  []byte
+}
+
+var (
+	_ Sequence  = new(DigestDialAckSequence)
+	_ Transport = new(DigestDialAckSequence)
+)
+
+// NewDigestDialAckSequence returns a new *DigestDialAckSequence expecting the
+// given version from the server, and initialized with the given C1 packet and
+// its digest, which requires the server to perform the digest handshake if
+// strict is true.
+func NewDigestDialAckSequence(supported byte, C1 *AckPacket, digest []byte,
+	strict bool) *DigestDialAckSequence {
+
+	return &DigestDialAckSequence{
+		Supported: supported,
+		C1:        C1,
+		Digest:    digest,
+		Strict:    strict,
+		S1:        new(AckPacket),
+	}
+}
+
+// Read implements the Sequence.Read function by reading the S0, S1 and S2
+// packets, and looking for the digest of S1. If S0 does not carry the expected
+// version, an error is returned. In strict mode, InvalidDigestErr is returned
+// if S1 carries no digest, or if S2 does not carry the digest expected in
+// response to C1. If the connection is encrypted, S1 must carry a digest, too.
+func (s *DigestDialAckSequence) Read(r io.Reader) error {
+	if err := (&VerisonSequence{Supported: s.Supported}).Read(r); err != nil {
+		return err
+	}
+
+	if err := s.S1.Read(r); err != nil {
+		return err
+	}
+
+	s2 := new(AckPacket)
+	if err := s2.Read(r); err != nil {
+		return err
+	}
+
+	s1 := encode(s.S1)
+
+	var schema int
+	if s.S1.Time2 != 0 {
+		s.s1Digest, schema = findDigest(s1, fmsKey[:36])
+	}
+
+	if s.key != nil {
+		if s.s1Digest == nil {
+			return InvalidDigestErr
+		}
+
+		offset := dhOffset(s1, schema)
+		s.peerKey = s1[offset : offset+KeyLen]
+
+		secret, err := s.key.Secret(s.peerKey)
+		if err != nil {
+			return err
+		}
+		s.secret = secret
+	}
+
+	if !s.Strict {
+		return nil
+	}
+
+	packet := encode(s2)
+	if s.s1Digest == nil || !hmac.Equal(packet[PacketLen-DigestLen:],
+		responseDigest(packet, s.Digest, fmsKey)) {
+
+		return InvalidDigestErr
+	}
+
+	return nil
+}
+
+// WriteTo implements the Sequence.WriteTo function. If S1 carried a digest, it
+// writes a C2 packet digested in response to S1. Otherwise, it writes a C2
+// packet echoing S1.
+func (s *DigestDialAckSequence) WriteTo(w io.Writer) error {
+	if s.s1Digest == nil {
+		return (&DialAckSequence{S1: s.S1}).WriteTo(w)
+	}
+
+	c2 := make([]byte, PacketLen)
+	rand.Read(c2)
+	copy(c2[PacketLen-DigestLen:], responseDigest(c2, s.s1Digest, fpKey))
+
+	if _, err := w.Write(c2); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Next implements the Sequence.Next function. Since there is no next function,
+// this function always returns nil.
+func (s *DigestDialAckSequence) Next() Sequence { return nil }
+
+// Wrap implements the Transport.Wrap function, encrypting the given connection
+// if it is to be encrypted.
+func (s *DigestDialAckSequence) Wrap(rw io.ReadWriter) io.ReadWriter {
+	if s.key == nil {
+		return rw
+	}
+
+	return encrypt(rw, s.secret, s.key.Public, s.peerKey)
+}
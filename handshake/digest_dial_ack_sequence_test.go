@@ -0,0 +1,43 @@
+package handshake_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/WatchBeam/rtmp/handshake"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestDialAckSequenceRejectsUndigestedS1WhenStrict(t *testing.T) {
+	c1 := &handshake.AckPacket{Payload: payload()}
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte(handshake.SupportedRTMPVersion)         // S0
+	(&handshake.AckPacket{Payload: payload()}).Write(buf) // S1
+	c1.Write(buf)                                         // S2
+
+	s := handshake.NewDigestDialAckSequence(
+		handshake.SupportedRTMPVersion, c1, make([]byte, 32), true,
+	)
+
+	assert.Equal(t, handshake.InvalidDigestErr, s.Read(buf))
+}
+
+func TestDigestDialAckSequenceEchoesUndigestedS1(t *testing.T) {
+	c1 := &handshake.AckPacket{Payload: payload()}
+	s1 := &handshake.AckPacket{Payload: payload()}
+
+	in := new(bytes.Buffer)
+	in.WriteByte(handshake.SupportedRTMPVersion)
+	s1.Write(in)
+	c1.Write(in)
+
+	s := handshake.NewDigestDialAckSequence(
+		handshake.SupportedRTMPVersion, c1, make([]byte, 32), false,
+	)
+	out := new(bytes.Buffer)
+
+	assert.Nil(t, s.Read(in))
+	assert.Nil(t, s.WriteTo(out))
+	assert.Equal(t, s1.Payload[:], out.Bytes()[8:])
+}
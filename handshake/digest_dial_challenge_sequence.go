@@ -0,0 +1,88 @@
+package handshake
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	// clientVersion is the version sent in the Time2 field of C1 during the
+	// digest handshake.
+	clientVersion uint32 = 0x09007c02
+)
+
+// DigestDialChallengeSequence is a handshake sequence played by the client
+// during the digest handshake. It is responsible for sending the version in
+// C0, together with the digested client challenge in C1, which also carries the
+// client's Diffie-Hellman public key if the connection is encrypted.
+type DigestDialChallengeSequence struct {
+	// Supported is the version byte sent in C0, which S0 must carry.
+	Supported byte
+	// Strict is true if the server must perform the digest handshake, too.
+	Strict bool
+	// Encrypted is true if the connection is encrypted (RTMPE).
+	Encrypted bool
+
+	// C1 is the packet sent to the server, set by WriteTo.
+	C1 *AckPacket
+
+	// digest is the digest carried by C1.
+	digest []byte
+	// key is the key pair whose public key is carried by C1, if the
+	// connection is encrypted.
+	key *dhKey
+}
+
+var _ Sequence = new(DigestDialChallengeSequence)
+
+// NewDigestDialChallengeSequence initializes and returns a new
+// *DigestDialChallengeSequence, sending the given version to the server.
+func NewDigestDialChallengeSequence(supported byte, strict bool) *DigestDialChallengeSequence {
+	return &DigestDialChallengeSequence{
+		Supported: supported,
+		Strict:    strict,
+	}
+}
+
+// Read implements the Sequence.Read function. Since the server waits for C0 and
+// C1 before answering, there is nothing to read, and a value of nil is always
+// returned here.
+func (c *DigestDialChallengeSequence) Read(r io.Reader) error { return nil }
+
+// WriteTo implements the Sequence.WriteTo function by writing the C0 packet,
+// and a new C1 packet, digested according to schema 0, with a single write.
+func (c *DigestDialChallengeSequence) WriteTo(w io.Writer) error {
+	c1 := make([]byte, PacketLen)
+	rand.Read(c1[8:])
+	binary.BigEndian.PutUint32(c1[4:], clientVersion)
+
+	if c.Encrypted {
+		key, err := newDHKey()
+		if err != nil {
+			return err
+		}
+
+		copy(c1[dhOffset(c1, 0):], key.Public)
+		c.key = key
+	}
+
+	offset := digestOffset(c1, 0)
+	c.digest = digestAt(c1, offset, fpKey[:30])
+	copy(c1[offset:], c.digest)
+	c.C1 = decode(c1)
+
+	if _, err := w.Write(append([]byte{c.Supported}, c1...)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Next implements the Sequence.Next function.
+func (c *DigestDialChallengeSequence) Next() Sequence {
+	s := NewDigestDialAckSequence(c.Supported, c.C1, c.digest, c.Strict)
+	s.key = c.key
+
+	return s
+}
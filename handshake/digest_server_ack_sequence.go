@@ -0,0 +1,61 @@
+package handshake
+
+import (
+	"crypto/hmac"
+	"io"
+)
+
+// DigestServerAckSequence is a type implementing the handshake.Sequence
+// interface, and is responsible for reading the C2 packet written by the client
+// during the digest handshake, and (in strict mode) for verifying that it was
+// digested in response to the digest carried by S1.
+type DigestServerAckSequence struct {
+	// Digest is the digest carried by S1, which C2 should acknowledge.
+	Digest []byte
+	// Strict is true if C2 must carry a valid digest. Otherwise, C2 is
+	// read, but not verified, as many clients do not digest it correctly.
+	Strict bool
+}
+
+var _ Sequence = new(DigestServerAckSequence)
+
+// NewDigestServerAckSequence returns a new *DigestServerAckSequence
+// initialized with the given digest of S1, which verifies C2 if strict is
+// true.
+func NewDigestServerAckSequence(digest []byte, strict bool) *DigestServerAckSequence {
+	return &DigestServerAckSequence{
+		Digest: digest,
+		Strict: strict,
+	}
+}
+
+// Read implements the Handshake.Read method by reading the C2 packet. In strict
+// mode, InvalidDigestErr is returned if C2 does not carry the digest expected
+// in response to S1.
+func (s *DigestServerAckSequence) Read(r io.Reader) error {
+	c2 := new(AckPacket)
+	if err := c2.Read(r); err != nil {
+		return err
+	}
+
+	if !s.Strict {
+		return nil
+	}
+
+	packet := encode(c2)
+	if !hmac.Equal(packet[PacketLen-DigestLen:],
+		responseDigest(packet, s.Digest, fpKey)) {
+
+		return InvalidDigestErr
+	}
+
+	return nil
+}
+
+// WriteTo implements the Sequence.WriteTo function. Since there is nothing to
+// write, a value of nil is always returned here.
+func (s *DigestServerAckSequence) WriteTo(w io.Writer) error { return nil }
+
+// Next implements the Sequence.Next function. Since there is no next function,
+// this function always returns nil.
+func (s *DigestServerAckSequence) Next() Sequence { return nil }
@@ -0,0 +1,45 @@
+package handshake_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/WatchBeam/rtmp/handshake"
+	"github.com/stretchr/testify/assert"
+)
+
+// DigestedC2 returns a C2 packet digested in response to the given digest of S1.
+func DigestedC2(digest []byte) []byte {
+	c2 := make([]byte, handshake.PacketLen)
+	rand.Read(c2)
+
+	end := handshake.PacketLen - handshake.DigestLen
+	copy(c2[end:], HMAC(HMAC(FPKey, digest), c2[:end]))
+
+	return c2
+}
+
+func TestDigestServerAckSequenceReadsDigestedC2(t *testing.T) {
+	digest := make([]byte, handshake.DigestLen)
+	rand.Read(digest)
+
+	s := handshake.NewDigestServerAckSequence(digest, true)
+
+	assert.Nil(t, s.Read(bytes.NewReader(DigestedC2(digest))))
+}
+
+func TestDigestServerAckSequenceRejectsUndigestedC2WhenStrict(t *testing.T) {
+	s := handshake.NewDigestServerAckSequence(make([]byte, 32), true)
+	c2 := make([]byte, handshake.PacketLen)
+
+	assert.Equal(t, handshake.InvalidDigestErr, s.Read(bytes.NewReader(c2)))
+}
+
+func TestDigestServerAckSequenceAcceptsUndigestedC2(t *testing.T) {
+	s := handshake.NewDigestServerAckSequence(make([]byte, 32), false)
+	c2 := make([]byte, handshake.PacketLen)
+
+	assert.Nil(t, s.Read(bytes.NewReader(c2)))
+	assert.Nil(t, s.Next())
+}
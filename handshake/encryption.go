@@ -0,0 +1,141 @@
+package handshake
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+)
+
+const (
+	// EncryptedRTMPVersion is the version tag sent by clients which encrypt
+	// the connection (RTMPE).
+	EncryptedRTMPVersion byte = 6
+
+	// KeyLen is the length of the Diffie-Hellman public keys exchanged
+	// during the encrypted handshake.
+	KeyLen int = 128
+)
+
+var (
+	// InvalidKeyErr is an error which is returned when the Diffie-Hellman
+	// public key sent by the peer during the encrypted handshake is out of
+	// range.
+	InvalidKeyErr = errors.New("rtmp/handshake: invalid public key")
+
+	// dhPrime is the 1024-bit prime of the Diffie-Hellman group used by
+	// RTMPE (the Second Oakley Group of RFC 2409).
+	dhPrime, _ = new(big.Int).SetString(
+		"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1"+
+			"29024E088A67CC74020BBEA63B139B22514A08798E3404DD"+
+			"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245"+
+			"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED"+
+			"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE65381"+
+			"FFFFFFFFFFFFFFFF", 16)
+	// dhGenerator is the generator of the Diffie-Hellman group.
+	dhGenerator = big.NewInt(2)
+)
+
+// Transport is implemented by Sequences which change the way that the
+// connection is read from and written to once the handshake has completed,
+// such as when it has been encrypted. See Handshaker.Conn.
+type Transport interface {
+	// Wrap returns the io.ReadWriter that takes the place of the given
+	// connection once the handshake has completed.
+	Wrap(rw io.ReadWriter) io.ReadWriter
+}
+
+// dhOffset returns the offset of the Diffie-Hellman public key within the
+// given encoded packet, according to the given digest schema (see
+// digestOffset). The public key never overlaps the digest.
+func dhOffset(packet []byte, schema int) int {
+	base, start := 1532, 772
+	if schema == 1 {
+		base, start = 768, 8
+	}
+
+	sum := int(packet[base]) + int(packet[base+1]) +
+		int(packet[base+2]) + int(packet[base+3])
+
+	return sum%632 + start
+}
+
+// dhKey is a Diffie-Hellman key pair.
+type dhKey struct {
+	// private is the private key.
+	private *big.Int
+	// Public is the encoded public key.
+	Public []byte
+}
+
+// newDHKey generates a new Diffie-Hellman key pair.
+func newDHKey() (*dhKey, error) {
+	private, err := rand.Int(rand.Reader, dhPrime)
+	if err != nil {
+		return nil, err
+	}
+
+	public := new(big.Int).Exp(dhGenerator, private, dhPrime)
+
+	return &dhKey{
+		private: private,
+		Public:  pad(public),
+	}, nil
+}
+
+// Secret returns the secret shared with the peer owning the given encoded
+// public key, or InvalidKeyErr if the public key is out of range.
+func (k *dhKey) Secret(peer []byte) ([]byte, error) {
+	y := new(big.Int).SetBytes(peer)
+	max := new(big.Int).Sub(dhPrime, big.NewInt(1))
+
+	if y.Cmp(big.NewInt(1)) <= 0 || y.Cmp(max) >= 0 {
+		return nil, InvalidKeyErr
+	}
+
+	return pad(new(big.Int).Exp(y, k.private, dhPrime)), nil
+}
+
+// pad encodes the given integer in KeyLen bytes.
+func pad(x *big.Int) []byte {
+	b := make([]byte, KeyLen)
+	return x.FillBytes(b)
+}
+
+// encrypt wraps the given connection in the RC4 streams derived from the given
+// shared secret, and the encoded public keys of this side of the connection
+// and of its peer. As done by all implementations of RTMPE, the first
+// PacketLen bytes of both streams are discarded.
+func encrypt(rw io.ReadWriter, secret, public, peer []byte) io.ReadWriter {
+	in, out := rc4Key(secret, public), rc4Key(secret, peer)
+
+	discard := make([]byte, PacketLen)
+	in.XORKeyStream(discard, discard)
+	out.XORKeyStream(discard, discard)
+
+	return &encryptedConn{
+		Reader: &cipher.StreamReader{S: in, R: rw},
+		Writer: &cipher.StreamWriter{S: out, W: rw},
+	}
+}
+
+// rc4Key returns the RC4 cipher keyed with the HMAC-SHA256 of the given public
+// key, keyed with the given shared secret.
+func rc4Key(secret, public []byte) *rc4.Cipher {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(public)
+
+	c, _ := rc4.NewCipher(mac.Sum(nil)[:16])
+
+	return c
+}
+
+// encryptedConn is a connection encrypted with RTMPE.
+type encryptedConn struct {
+	io.Reader
+	io.Writer
+}
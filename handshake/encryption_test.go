@@ -0,0 +1,71 @@
+package handshake_test
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/WatchBeam/rtmp/handshake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RecordedConn is a net.Conn which records everything written to it.
+type RecordedConn struct {
+	net.Conn
+	Written bytes.Buffer
+}
+
+func (c *RecordedConn) Write(p []byte) (int, error) {
+	c.Written.Write(p)
+	return c.Conn.Write(p)
+}
+
+func TestEncryptedHandshakesEncryptTheConnection(t *testing.T) {
+	cconn, sconn := Loopback(t)
+	defer cconn.Close()
+	defer sconn.Close()
+
+	recorded := &RecordedConn{Conn: cconn}
+
+	server := handshake.With(&handshake.Param{Conn: sconn})
+	client := handshake.With(&handshake.Param{
+		Conn:      recorded,
+		Role:      handshake.RoleClient,
+		Encrypted: true,
+	})
+
+	errs := make(chan error)
+	go func() { errs <- server.Handshake() }()
+
+	require.Nil(t, client.Handshake())
+	require.Nil(t, <-errs)
+
+	go client.Conn().Write([]byte("hello"))
+
+	received := make([]byte, 5)
+	_, err := io.ReadFull(server.Conn(), received)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello"), received)
+
+	sent := recorded.Written.Bytes()
+	assert.Len(t, sent, 1+2*handshake.PacketLen+5)
+	assert.NotEqual(t, []byte("hello"), sent[len(sent)-5:])
+
+	go server.Conn().Write([]byte("world"))
+
+	_, err = io.ReadFull(client.Conn(), received)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("world"), received)
+}
+
+func TestEncryptedHandshakesRequireDigests(t *testing.T) {
+	buf := new(bytes.Buffer)
+	(&handshake.AckPacket{Time2: 1, Payload: payload()}).Write(buf)
+
+	c := handshake.NewDigestClientAckSequence(false)
+	c.Encrypted = true
+
+	assert.Equal(t, handshake.InvalidDigestErr, c.Read(buf))
+}
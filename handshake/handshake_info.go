@@ -0,0 +1,107 @@
+package handshake
+
+// Scheme is the handshake scheme used by a client.
+type Scheme byte
+
+const (
+	// SchemeUnknown is the Scheme of clients whose C1 was never read,
+	// such as clients that sent an unsupported version.
+	SchemeUnknown Scheme = iota
+	// SchemeSimple is the simple handshake, where C1 carries no digest.
+	SchemeSimple
+	// SchemeDigest0 is the digest handshake, where the digest carried by
+	// C1 is found in the first half of its payload (schema 0).
+	SchemeDigest0
+	// SchemeDigest1 is the digest handshake, where the digest carried by
+	// C1 is found in the second half of its payload (schema 1).
+	SchemeDigest1
+)
+
+// String returns a short name for the Scheme, suitable for breaking down
+// metrics.
+func (s Scheme) String() string {
+	switch s {
+	case SchemeSimple:
+		return "simple"
+	case SchemeDigest0:
+		return "digest0"
+	case SchemeDigest1:
+		return "digest1"
+	}
+
+	return "unknown"
+}
+
+// HandshakeInfo holds what was learned about a client while handshaking with
+// it, which helps telling apart the implementations (Flash Player, encoders,
+// and so on) that clients are using.
+type HandshakeInfo struct {
+	// Version is the version byte sent by the client in C0.
+	Version byte
+	// Time is the epoch sent by the client in the Time1 field of C1.
+	Time uint32
+	// ClientVersion is the version of the client's implementation, sent
+	// in the Time2 field of C1. It is zero for most clients using the
+	// simple handshake.
+	ClientVersion uint32
+	// Scheme is the handshake scheme used by the client, or SchemeUnknown
+	// if C1 was never read.
+	Scheme Scheme
+	// Encrypted is true if the client asked for the connection to be
+	// encrypted (RTMPE).
+	Encrypted bool
+}
+
+// Informer is implemented by Sequences which learn about the client, when
+// playing RoleServer. See Handshaker.Info.
+type Informer interface {
+	// Inform records what the Sequence has learned about the client into
+	// the given HandshakeInfo. It is called once the Sequence has read
+	// from the client, even if reading failed.
+	Inform(info *HandshakeInfo)
+}
+
+var (
+	_ Informer = new(VerisonSequence)
+	_ Informer = new(ClientAckSequence)
+	_ Informer = new(DigestClientAckSequence)
+)
+
+// Inform implements the Informer.Inform function, recording the version byte
+// sent by the client, even if it is not a supported one.
+func (v *VerisonSequence) Inform(info *HandshakeInfo) {
+	info.Version = v.Received
+	info.Encrypted = v.Encrypted
+}
+
+// Inform implements the Informer.Inform function, recording the contents of
+// C1, if it was read.
+func (c *ClientAckSequence) Inform(info *HandshakeInfo) {
+	if !c.read {
+		return
+	}
+
+	info.Time = c.C1.Time1
+	info.ClientVersion = c.C1.Time2
+	info.Scheme = SchemeSimple
+}
+
+// Inform implements the Informer.Inform function, recording the contents of
+// C1, and the schema of its digest, if any, if C1 was read.
+func (c *DigestClientAckSequence) Inform(info *HandshakeInfo) {
+	if !c.read {
+		return
+	}
+
+	info.Time = c.C1.Time1
+	info.ClientVersion = c.C1.Time2
+
+	switch {
+	case c.digest == nil:
+		info.Scheme = SchemeSimple
+	case c.schema == 0:
+		info.Scheme = SchemeDigest0
+	default:
+		info.Scheme = SchemeDigest1
+	}
+}
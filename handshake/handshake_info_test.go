@@ -0,0 +1,69 @@
+package handshake_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/WatchBeam/rtmp/handshake"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandshakersRecordTheDigestScheme(t *testing.T) {
+	c1, _ := DigestedC1(1)
+
+	conn := &struct {
+		io.Reader
+		io.Writer
+	}{bytes.NewReader(append([]byte{6}, c1...)), new(bytes.Buffer)}
+
+	h := handshake.With(&handshake.Param{Conn: conn})
+	h.Handshake()
+
+	assert.Equal(t, handshake.HandshakeInfo{
+		Version:       6,
+		ClientVersion: 0x80000702,
+		Scheme:        handshake.SchemeDigest1,
+		Encrypted:     true,
+	}, h.Info())
+}
+
+func TestHandshakersRecordTheSimpleScheme(t *testing.T) {
+	cconn, sconn := Loopback(t)
+	defer cconn.Close()
+	defer sconn.Close()
+
+	client := handshake.With(&handshake.Param{
+		Conn: cconn,
+		Role: handshake.RoleClient,
+	})
+	go client.Handshake()
+
+	h := handshake.With(&handshake.Param{Conn: sconn})
+
+	assert.Nil(t, h.Handshake())
+	assert.Equal(t, handshake.HandshakeInfo{
+		Version: 3,
+		Scheme:  handshake.SchemeSimple,
+	}, h.Info())
+}
+
+func TestSchemesHaveNames(t *testing.T) {
+	assert.Equal(t, "digest0", handshake.SchemeDigest0.String())
+	assert.Equal(t, "unknown", handshake.SchemeUnknown.String())
+}
+
+func TestHandshakersRecordUnsupportedVersions(t *testing.T) {
+	conn := &struct {
+		io.Reader
+		io.Writer
+	}{bytes.NewReader([]byte("GET / HTTP/1.1\r\n")), new(bytes.Buffer)}
+
+	h := handshake.With(&handshake.Param{Conn: conn})
+
+	assert.NotNil(t, h.Handshake())
+	assert.Equal(t, handshake.HandshakeInfo{
+		Version: 'G',
+		Scheme:  handshake.SchemeUnknown,
+	}, h.Info())
+}
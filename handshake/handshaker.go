@@ -1,6 +1,17 @@
 package handshake
 
-import "io"
+import (
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+var (
+	// TimeoutErr is an error which is returned when the handshake did not
+	// complete within the Timeout given in the Param.
+	TimeoutErr = errors.New("rtmp/handshake: timed out")
+)
 
 // Handshaker preforms the RTMP handshake with the owned io.ReadWriter by
 // cycling through a linked-list of sequences.
@@ -18,29 +29,81 @@ type Handshaker struct {
 	// initial represents the initial Sequence to begin the entire handshake
 	// operation with.
 	current Sequence
+	// timeout bounds the entire handshake operation, if positive.
+	timeout time.Duration
+	// transports are the Sequences which have been performed that are
+	// Transports, in order.
+	transports []Transport
+	// info is what the Sequences which are Informers have learned about
+	// the client.
+	info HandshakeInfo
 }
 
+// Role is the role played by a Handshaker: either that of the server, or that
+// of the client.
+type Role byte
+
+const (
+	// RoleServer is the role of the server, which waits for the client to
+	// begin the handshake.
+	RoleServer Role = iota
+	// RoleClient is the role of the client, which dials out to a server.
+	RoleClient
+)
+
 // Param wraps each argument passed to the constructor `func With`.
 type Param struct {
 	// Conn is the connection which the Handshaker will read and write to.
 	// This parameter is required.
 	Conn io.ReadWriter
 	// Initial is the starting sequence. If not specified, RTMP will default
-	// to the VersionSequence type, which is the initial sequence as
-	// according to the RTMP specification.
+	// to the initial sequence of the given Role, as according to the RTMP
+	// specification: the VersionSequence type for RoleServer, or the
+	// DialVersionSequence type for RoleClient.
 	Initial Sequence
+
+	// Role is the role played in the handshake, which is RoleServer by
+	// default.
+	Role Role
+	// Digest is true if the digest handshake is performed when playing
+	// RoleClient, rather than the simple handshake. When playing
+	// RoleServer, the digest handshake is performed whenever the client
+	// begins it.
+	Digest bool
+	// Strict is true if the peer must perform the digest handshake.
+	Strict bool
+	// Encrypted is true if the connection is encrypted (RTMPE) when playing
+	// RoleClient, which implies Digest. When playing RoleServer, the
+	// connection is encrypted whenever the client asks for it. See
+	// Handshaker.Conn.
+	Encrypted bool
+
+	// Timeout bounds the time that the entire handshake may take, if it is
+	// positive and the Conn has a SetDeadline function (as a net.Conn does).
+	// The deadline is cleared once the handshake has completed.
+	Timeout time.Duration
 }
 
 // With returns a new Handshaker initialized with the given Param.
 func With(p *Param) *Handshaker {
 	h := &Handshaker{
-		rw: p.Conn,
+		rw:      p.Conn,
+		timeout: p.Timeout,
 	}
 
-	if p.Initial != nil {
+	switch {
+	case p.Initial != nil:
 		h.current = p.Initial
-	} else {
-		h.current = NewVersionSequence()
+	case p.Role == RoleClient:
+		v := NewDialVersionSequence(p.Digest, p.Strict)
+		v.Encrypted = p.Encrypted
+
+		h.current = v
+	default:
+		h.current = &VerisonSequence{
+			Supported: SupportedRTMPVersion,
+			Strict:    p.Strict,
+		}
 	}
 
 	return h
@@ -54,16 +117,66 @@ func With(p *Param) *Handshaker {
 //
 // If nil is returned, then the handshake completed succesfully without error,
 // and the `current` handshake.Sequence will also be nil.
+//
+// Once the handshake has completed, the connection is wrapped by each of the
+// Sequences performed that is a Transport. See Conn.
+//
+// If the handshake does not complete within the Timeout given in the Param,
+// TimeoutErr is returned.
 func (h *Handshaker) Handshake() error {
+	if d, ok := h.rw.(deadliner); ok && h.timeout > 0 {
+		d.SetDeadline(time.Now().Add(h.timeout))
+		defer d.SetDeadline(time.Time{})
+	}
+
 	for ; h.current != nil; h.current = h.current.Next() {
-		if err := h.current.Read(h.rw); err != nil {
-			return err
+		if t, ok := h.current.(Transport); ok {
+			h.transports = append(h.transports, t)
+		}
+
+		err := h.current.Read(h.rw)
+		if i, ok := h.current.(Informer); ok {
+			i.Inform(&h.info)
+		}
+
+		if err != nil {
+			return timeoutErr(err)
 		}
 
 		if err := h.current.WriteTo(h.rw); err != nil {
-			return err
+			return timeoutErr(err)
 		}
 	}
 
+	for _, t := range h.transports {
+		h.rw = t.Wrap(h.rw)
+	}
+
 	return nil
 }
+
+// Conn returns the connection that the Handshaker handshakes with, which once
+// the handshake has completed, is to be read from and written to in place of
+// the connection given in the Param, as it may have been encrypted.
+func (h *Handshaker) Conn() io.ReadWriter { return h.rw }
+
+// Info returns what was learned about the client while handshaking with it, as
+// a server. It may be called once Handshake has returned, even if the handshake
+// failed, in which case it holds whatever was learned until then.
+func (h *Handshaker) Info() HandshakeInfo { return h.info }
+
+// deadliner is implemented by connections which support deadlines, such as
+// net.Conn.
+type deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// timeoutErr returns TimeoutErr if the given error is a timeout, or the given
+// error otherwise.
+func timeoutErr(err error) error {
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return TimeoutErr
+	}
+
+	return err
+}
@@ -3,10 +3,14 @@ package handshake_test
 import (
 	"bytes"
 	"errors"
+	"io"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/WatchBeam/rtmp/handshake"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestItConstructsNewHandshakers(t *testing.T) {
@@ -88,3 +92,173 @@ func TestItCyclesToNextSequence(t *testing.T) {
 	initial.AssertExpectations(t)
 	next.AssertExpectations(t)
 }
+
+// Loopback returns both ends of a TCP connection over the loopback interface.
+// Unlike net.Pipe, it buffers writes, as the clients dialing RTMP servers
+// expect: C0 and C1 are written together before S0 is read.
+func Loopback(t *testing.T) (cconn, sconn net.Conn) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer l.Close()
+
+	cconn, err = net.Dial("tcp", l.Addr().String())
+	require.Nil(t, err)
+
+	sconn, err = l.Accept()
+	require.Nil(t, err)
+
+	return cconn, sconn
+}
+
+// HandshakeOverLoopback runs a Handshaker playing RoleClient with the given
+// client Param against one playing RoleServer with the given server Param over
+// a Loopback connection, returning the errors that each of them encountered.
+func HandshakeOverLoopback(t *testing.T,
+	client, server *handshake.Param) (cerr, serr error) {
+
+	cconn, sconn := Loopback(t)
+	defer cconn.Close()
+	defer sconn.Close()
+
+	client.Role = handshake.RoleClient
+	client.Conn = cconn
+	server.Conn = sconn
+
+	errs := make(chan error)
+	go func() {
+		err := handshake.With(server).Handshake()
+		if err != nil {
+			sconn.Close()
+		}
+
+		errs <- err
+	}()
+
+	cerr = handshake.With(client).Handshake()
+	if cerr != nil {
+		cconn.Close()
+	}
+
+	return cerr, <-errs
+}
+
+func TestClientsHandshakeWithServers(t *testing.T) {
+	for _, p := range []struct {
+		Client, Server *handshake.Param
+	}{
+		{&handshake.Param{}, &handshake.Param{}},
+		{&handshake.Param{Digest: true}, &handshake.Param{}},
+		{&handshake.Param{Digest: true}, &handshake.Param{Strict: true}},
+		{&handshake.Param{Digest: true, Strict: true}, &handshake.Param{}},
+	} {
+		cerr, serr := HandshakeOverLoopback(t, p.Client, p.Server)
+
+		assert.Nil(t, cerr)
+		assert.Nil(t, serr)
+	}
+}
+
+func TestClientsSendC0AndC1BeforeReading(t *testing.T) {
+	for _, p := range []*handshake.Param{
+		{Role: handshake.RoleClient},
+		{Role: handshake.RoleClient, Digest: true},
+	} {
+		cconn, sconn := net.Pipe()
+		p.Conn = cconn
+
+		errs := make(chan error)
+		go func() { errs <- handshake.With(p).Handshake() }()
+
+		// Like nginx-rtmp, SRS and Wowza, wait for C0 and C1 before
+		// answering, over an unbuffered connection.
+		c0c1 := make([]byte, 1+handshake.PacketLen)
+		_, err := io.ReadFull(sconn, c0c1)
+		require.Nil(t, err)
+		assert.Equal(t, handshake.SupportedRTMPVersion, c0c1[0])
+
+		s0s1 := make([]byte, 1+handshake.PacketLen)
+		s0s1[0] = handshake.SupportedRTMPVersion
+
+		_, err = sconn.Write(append(s0s1, c0c1[1:]...))
+		require.Nil(t, err)
+
+		_, err = io.ReadFull(sconn, make([]byte, handshake.PacketLen))
+		assert.Nil(t, err)
+		assert.Nil(t, <-errs)
+
+		cconn.Close()
+		sconn.Close()
+	}
+}
+
+func TestStrictServersRejectSimpleClients(t *testing.T) {
+	cerr, serr := HandshakeOverLoopback(
+		t, &handshake.Param{}, &handshake.Param{Strict: true},
+	)
+
+	assert.NotNil(t, cerr)
+	assert.Equal(t, handshake.InvalidDigestErr, serr)
+}
+
+func TestHandshakesTimeOutWhenThePeerStalls(t *testing.T) {
+	cconn, sconn := net.Pipe()
+	defer cconn.Close()
+	defer sconn.Close()
+
+	h := handshake.With(&handshake.Param{
+		Conn:    sconn,
+		Timeout: 10 * time.Millisecond,
+	})
+
+	assert.Equal(t, handshake.TimeoutErr, h.Handshake())
+}
+
+func TestHandshakesTimeOutWhenThePeerTrickles(t *testing.T) {
+	cconn, sconn := net.Pipe()
+	defer cconn.Close()
+	defer sconn.Close()
+
+	h := handshake.With(&handshake.Param{
+		Conn:    sconn,
+		Timeout: 50 * time.Millisecond,
+	})
+
+	go func() {
+		b := make([]byte, 1)
+		cconn.Write([]byte{handshake.SupportedRTMPVersion})
+		cconn.Read(b)
+
+		for i := 0; i < handshake.PacketLen; i++ {
+			if _, err := cconn.Write(b); err != nil {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	assert.Equal(t, handshake.TimeoutErr, h.Handshake())
+}
+
+func TestHandshakesClearTheirDeadline(t *testing.T) {
+	cconn, sconn := Loopback(t)
+	defer cconn.Close()
+	defer sconn.Close()
+
+	client := handshake.With(&handshake.Param{
+		Conn: cconn,
+		Role: handshake.RoleClient,
+	})
+	go client.Handshake()
+
+	server := handshake.With(&handshake.Param{
+		Conn:    sconn,
+		Timeout: 10 * time.Millisecond,
+	})
+	require.Nil(t, server.Handshake())
+
+	time.Sleep(20 * time.Millisecond)
+	go cconn.Write([]byte{1})
+
+	_, err := sconn.Read(make([]byte, 1))
+	assert.Nil(t, err)
+}
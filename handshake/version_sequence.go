@@ -16,6 +16,16 @@ const (
 type VerisonSequence struct {
 	// Supported is the supported version byte that this server can handle.
 	Supported byte
+	// Strict is true if the client must perform the digest handshake. See
+	// DigestClientAckSequence.
+	Strict bool
+	// Encrypted is set to true when the client sends the
+	// EncryptedRTMPVersion instead of the Supported version, asking for
+	// the connection to be encrypted (RTMPE).
+	Encrypted bool
+	// Received is the version byte read from the client, whether it is
+	// supported or not. It is zero until one has been read.
+	Received byte
 }
 
 var _ Sequence = new(VerisonSequence)
@@ -30,14 +40,21 @@ func NewVersionSequence() *VerisonSequence {
 
 // Read reads the version byte off of the io.Reader, returning an error if
 // either there was an error reading, or a version mismatch. Otherwise, a value
-// of nil was retuend.
+// of nil was retuend. The EncryptedRTMPVersion is accepted as well, in which
+// case the VerisonSequence becomes Encrypted.
 func (v *VerisonSequence) Read(r io.Reader) error {
 	var b [1]byte
-	if _, err := r.Read(b[:]); err != nil {
+	if _, err := io.ReadFull(r, b[:]); err != nil {
 		return err
 	}
 
-	if b[0] != v.Supported {
+	v.Received = b[0]
+
+	switch b[0] {
+	case v.Supported:
+	case EncryptedRTMPVersion:
+		v.Encrypted = true
+	default:
 		return fmt.Errorf(
 			"rtmp/handshake: unsupported version %v", b[0])
 	}
@@ -45,19 +62,29 @@ func (v *VerisonSequence) Read(r io.Reader) error {
 	return nil
 }
 
-// WriteTo writes out the version that this RTMP server supports. If any write
-// error was encountered, it will be returned immediately. Otherwise, a value of
-// nil is returned.
+// WriteTo writes out the version that this RTMP server supports, or the
+// EncryptedRTMPVersion if the VerisonSequence is Encrypted. If any write error
+// was encountered, it will be returned immediately. Otherwise, a value of nil
+// is returned.
 func (v *VerisonSequence) WriteTo(w io.Writer) error {
-	if _, err := w.Write([]byte{v.Supported}); err != nil {
+	version := v.Supported
+	if v.Encrypted {
+		version = EncryptedRTMPVersion
+	}
+
+	if _, err := w.Write([]byte{version}); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// Next returns the ClientAckSequence, which is the next step in the RTMP
-// handshake, according to the specification.
+// Next returns the DigestClientAckSequence, which is the next step in the RTMP
+// handshake, according to the specification. It falls back to the simple
+// handshake unless the VerisonSequence is Strict or Encrypted.
 func (v *VerisonSequence) Next() Sequence {
-	return NewClientAckSequence()
+	c := NewDigestClientAckSequence(v.Strict)
+	c.Encrypted = v.Encrypted
+
+	return c
 }
@@ -35,3 +35,20 @@ func TestItWritesVersion(t *testing.T) {
 	assert.Nil(t, v.WriteTo(buf))
 	assert.Equal(t, []byte{0x3}, buf.Bytes()[:1])
 }
+
+func TestItIsFollowedByTheDigestHandshake(t *testing.T) {
+	v := &handshake.VerisonSequence{Supported: 3, Strict: true}
+
+	assert.Equal(t, handshake.NewDigestClientAckSequence(true), v.Next())
+}
+
+func TestItAcceptsTheEncryptedVersion(t *testing.T) {
+	buf := new(bytes.Buffer)
+	v := handshake.NewVersionSequence()
+
+	assert.Nil(t, v.Read(bytes.NewBuffer([]byte{0x6})))
+	assert.True(t, v.Encrypted)
+
+	assert.Nil(t, v.WriteTo(buf))
+	assert.Equal(t, []byte{0x6}, buf.Bytes())
+}